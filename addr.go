@@ -11,13 +11,14 @@ import (
 type Addr struct {
 	*net.IPNet
 	Label       string
-	Flags       int
+	Flags       int // IFA_F flags, IN6_IFF flags for an IPv6 address
 	Scope       int
 	Peer        *net.IPNet
 	Broadcast   net.IP
 	PreferedLft int
 	ValidLft    int
 	LinkIndex   int
+	Vhid        int // CARP virtual host id, 0 for none
 }
 
 // String returns $ip/$netmask $label
@@ -25,3 +26,26 @@ func (a Addr) String() string {
 	return strings.TrimSpace(fmt.Sprintf("%s %s", a.IPNet, a.Label))
 }
 
+// AddrUpdate is an address change notification, of an address added
+// (NewAddr) or deleted.
+type AddrUpdate struct {
+	LinkAddress net.IPNet
+	LinkIndex   int
+	Flags       int // as Addr.Flags
+	Scope       int
+	PreferedLft int
+	ValidLft    int
+	NewAddr     bool // true=added false=deleted
+}
+
+// ErrDuplicateAddress is the error of an IPv6 address that duplicate
+// address detection found to be in use by another node. The address stays
+// on the link, marked IN6_IFF_DUPLICATED, and can not be used.
+type ErrDuplicateAddress struct {
+	LinkIndex int
+	IP        net.IP
+}
+
+func (e ErrDuplicateAddress) Error() string {
+	return fmt.Sprintf("duplicate address %s on link %d", e.IP, e.LinkIndex)
+}
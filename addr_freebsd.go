@@ -1,23 +1,62 @@
 package netlink
 
 import (
+	"errors"
 	"fmt"
 	"net"
 	"strings"
+	"syscall"
 	"unsafe"
 
 	"github.com/oss-fun/netlink/nl"
+	netroute "golang.org/x/net/route"
+	"github.com/oss-fun/vnet"
 	"golang.org/x/sys/unix"
 	
 	"github.com/oss-fun/netlink/nlunix"
 )
 
+// Flags of IPv6 addresses, see netinet6/in6_var.h. Addr.Flags of an
+// IPv6 address holds these.
+const (
+	IN6_IFF_ANYCAST       = 0x01  // anycast address
+	IN6_IFF_TENTATIVE     = 0x02  // duplicate address detection pending
+	IN6_IFF_DUPLICATED    = 0x04  // duplicate address detected
+	IN6_IFF_DETACHED      = 0x08  // may be detached from the link
+	IN6_IFF_DEPRECATED    = 0x10  // preferred lifetime expired
+	IN6_IFF_NODAD         = 0x20  // no duplicate address detection
+	IN6_IFF_AUTOCONF      = 0x40  // autoconfigurable address
+	IN6_IFF_TEMPORARY     = 0x80  // temporary (anonymous) address
+	IN6_IFF_PREFER_SOURCE = 0x100 // preferred as source address
+)
+
+// in6AddFlags are the IN6_IFF flags AddrAdd can set.
+const in6AddFlags = IN6_IFF_ANYCAST | IN6_IFF_PREFER_SOURCE | IN6_IFF_NODAD | IN6_IFF_AUTOCONF
+
+// in6StateFlags are the IN6_IFF flags the kernel maintains. AddrList
+// reports them, AddrAdd ignores them.
+const in6StateFlags = IN6_IFF_TENTATIVE | IN6_IFF_DUPLICATED | IN6_IFF_DETACHED | IN6_IFF_DEPRECATED | IN6_IFF_TEMPORARY
+
+// AddrOptions change how adding an address that exists already and
+// deleting one that does not exist are reported.
+type AddrOptions struct {
+	// Idempotent makes both succeed without changing anything, as reconcile
+	// loops want. Otherwise they fail with an error wrapping EEXIST and
+	// EADDRNOTAVAIL.
+	Idempotent bool
+}
+
 // AddrAdd will add an IP address to a link device.
 //
 // Equivalent to: `ip addr add $addr dev $link`
 //
 // If `addr` is an IPv4 address and the broadcast address is not given, it
 // will be automatically computed based on the IP mask if /30 or larger.
+// On a point-to-point link the Peer is the destination address.
+// For an IPv6 address, Flags are IN6_IFF flags and PreferedLft and
+// ValidLft are the lifetimes in seconds, infinite if both are 0. The
+// flags the kernel maintains, such as IN6_IFF_TENTATIVE, are ignored, so
+// an address from AddrList can be added as is.
 func AddrAdd(link Link, addr *Addr) error {
 	return pkgHandle.AddrAdd(link, addr)
 }
@@ -28,29 +67,196 @@ func AddrAdd(link Link, addr *Addr) error {
 //
 // If `addr` is an IPv4 address and the broadcast address is not given, it
 // will be automatically computed based on the IP mask if /30 or larger.
+// On a point-to-point link the Peer is the destination address.
+// For an IPv6 address, Flags are IN6_IFF flags and PreferedLft and
+// ValidLft are the lifetimes in seconds, infinite if both are 0. The
+// flags the kernel maintains, such as IN6_IFF_TENTATIVE, are ignored, so
+// an address from AddrList can be added as is.
 func (h *Handle) AddrAdd(link Link, addr *Addr) error {
+	return h.AddrAddWithOptions(link, addr, AddrOptions{})
+}
+
+// AddrAddWithOptions works like AddrAdd with the options.
+//
+// Whether the address exists is looked up before it is added, so an
+// address another process adds in between is updated in place, as
+// AddrReplace does, rather than reported as existing.
+func AddrAddWithOptions(link Link, addr *Addr, options AddrOptions) error {
+	return pkgHandle.AddrAddWithOptions(link, addr, options)
+}
+
+// AddrAddWithOptions works like AddrAdd with the options.
+//
+// Whether the address exists is looked up before it is added, so an
+// address another process adds in between is updated in place, as
+// AddrReplace does, rather than reported as existing.
+func (h *Handle) AddrAddWithOptions(link Link, addr *Addr, options AddrOptions) error {
+	if !h.inCurrentVnet() {
+		return errAddrForeignVnet
+	}
+	name, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+	exists, err := addrExists(name, addr.IP)
+	if err != nil {
+		return err
+	}
+	if exists {
+		if options.Idempotent {
+			return nil
+		}
+		return fmt.Errorf("address %s exists on %s: %w", addr.IP, name, unix.EEXIST)
+	}
+	return addrSet(name, addr)
+}
+
+// AddrReplace will replace (or, if not present, add) an IP address on a
+// link device. The lifetimes, flags, broadcast address and vhid of an
+// existing IPv6 address are updated in place; the kernel removes an
+// existing IPv4 address and adds it again.
+//
+// Equivalent to: `ip addr replace $addr dev $link`
+func AddrReplace(link Link, addr *Addr) error {
+	return pkgHandle.AddrReplace(link, addr)
+}
+
+// AddrReplace will replace (or, if not present, add) an IP address on a
+// link device. The lifetimes, flags, broadcast address and vhid of an
+// existing IPv6 address are updated in place; the kernel removes an
+// existing IPv4 address and adds it again.
+//
+// Equivalent to: `ip addr replace $addr dev $link`
+func (h *Handle) AddrReplace(link Link, addr *Addr) error {
+	if !h.inCurrentVnet() {
+		return errAddrForeignVnet
+	}
+	name, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+	return addrSet(name, addr)
+}
+
+// errAddrForeignVnet is returned when adding an address with a handle on
+// another vnet, which SIOCAIFADDR and SIOCAIFADDR_IN6 do not reach.
+var errAddrForeignVnet = fmt.Errorf("adding an address on another vnet is not supported: %w", unix.EOPNOTSUPP)
+
+// addrSet adds the address to the link name with SIOCAIFADDR or
+// SIOCAIFADDR_IN6, which update the address if it exists already.
+func addrSet(name string, addr *Addr) error {
 	/* ioctl用ソケットを作成 */
-	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
+	family := unix.AF_INET
+	if addr.IP.To4() == nil {
+		family = unix.AF_INET6
+	}
+	fd, err := unix.Socket(family, unix.SOCK_DGRAM, 0)
 	if err != nil {
 		return fmt.Errorf("Socket error: %v", err)
 	}
 	defer unix.Close(fd)
 
+	if family == unix.AF_INET6 {
+		return addrAddInet6(fd, name, addr)
+	}
+	return addrAddInet(fd, name, addr)
+}
+
+// addrExists reports whether the link name has the address, looked up in
+// the NET_RT_IFLIST sysctl so that it works without netlink.
+func addrExists(name string, ip net.IP) (bool, error) {
+	fd, err := getSocketUDP()
+	if err != nil {
+		return false, fmt.Errorf("socket error: %w", err)
+	}
+	index, err := linkIndexByName(fd, name)
+	unix.Close(fd)
+	if err != nil {
+		return false, LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+
+	rib, err := netroute.FetchRIB(unix.AF_UNSPEC, netroute.RIBTypeInterface, index)
+	if err != nil {
+		return false, fmt.Errorf("sysctl NET_RT_IFLIST error: %w", err)
+	}
+	msgs, err := netroute.ParseRIB(netroute.RIBTypeInterface, rib)
+	if err != nil {
+		return false, err
+	}
+	for _, m := range msgs {
+		ifam, ok := m.(*netroute.InterfaceAddrMessage)
+		if !ok || ifam.Index != index || len(ifam.Addrs) <= unix.RTAX_IFA {
+			continue
+		}
+		switch a := ifam.Addrs[unix.RTAX_IFA].(type) {
+		case *netroute.Inet4Addr:
+			if ip.Equal(net.IP(a.IP[:])) {
+				return true, nil
+			}
+		case *netroute.Inet6Addr:
+			if ip.Equal(net.IP(a.IP[:])) {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// addrAddInet adds the IPv4 address to the link name using SIOCAIFADDR.
+// On an IFF_POINTOPOINT link the destination is the Peer, the address
+// itself if there is none; on other links the broadcast address is set
+// as the netlink path sets it.
+func addrAddInet(fd int, name string, addr *Addr) error {
+	rawFlags, err := linkRawFlags(fd, name)
+	if errors.Is(err, unix.ENXIO) {
+		return LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	if err != nil {
+		return err
+	}
+
 	/* アドレス指定用構造体を作成 */
 	var ifra Ifaliasreq
-	copy(ifra.ifra_name[:], link.Attrs().Name)
+	copy(ifra.ifra_name[:unix.IFNAMSIZ-1], name)
 
+	mask := addr.Mask
+	if addr.Peer != nil {
+		mask = addr.Peer.Mask
+	}
+	if mask == nil {
+		mask = net.CIDRMask(32, 32)
+	}
 	iaddr, err := ipToSockaddrIn(addr.IP)
 	if err != nil {
 		return fmt.Errorf("ipToSockaddrIn error: %v", err)
 	}
-	imask, err := ipToSockaddrIn(net.IP(addr.Mask))
+	imask, err := ipToSockaddrIn(net.IP(mask))
 	if err != nil {
 		return fmt.Errorf("ipToSockaddrIn error: %v", err)
 	}
-
 	ifra.ifra_addr = iaddr
 	ifra.ifra_mask = imask
+	ifra.ifra_vhid = int32(addr.Vhid)
+
+	// ifra_broadaddr is ifra_dstaddr on point-to-point links
+	if rawFlags&unix.IFF_POINTOPOINT != 0 {
+		dst := addr.IP
+		if addr.Peer != nil {
+			dst = addr.Peer.IP
+		}
+		if ifra.ifra_broadaddr, err = ipToSockaddrIn(dst); err != nil {
+			return fmt.Errorf("ipToSockaddrIn error: %v", err)
+		}
+	} else {
+		if addr.Broadcast == nil {
+			addr.Broadcast = addrBroadcast(addr.IP, mask)
+		}
+		if addr.Broadcast != nil {
+			if ifra.ifra_broadaddr, err = ipToSockaddrIn(addr.Broadcast); err != nil {
+				return fmt.Errorf("ipToSockaddrIn error: %v", err)
+			}
+		}
+	}
 
 	/* ioctl syscall */
 	_, _, errno := unix.Syscall(
@@ -59,13 +265,30 @@ func (h *Handle) AddrAdd(link Link, addr *Addr) error {
 		uintptr(unix.SIOCAIFADDR),
 		uintptr(unsafe.Pointer(&ifra)),
 	)
+	if errno == unix.ENXIO {
+		return LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
 	if errno != 0 {
-		return fmt.Errorf("ioctl error: %v", errno)
+		return fmt.Errorf("ioctl SIOCAIFADDR error: %w", errno)
 	}
-
 	return nil
 }
 
+// addrBroadcast returns the broadcast address of the IPv4 address with
+// the mask, nil if the subnet is too small to sensibly have one (/31 or
+// smaller). See: RFC 3021
+func addrBroadcast(ip net.IP, mask net.IPMask) net.IP {
+	ip4 := ip.To4()
+	if ones, bits := mask.Size(); ip4 == nil || bits != 32 || ones >= 31 {
+		return nil
+	}
+	broadcast := make(net.IP, net.IPv4len)
+	for i := range ip4 {
+		broadcast[i] = ip4[i] | ^mask[i]
+	}
+	return broadcast
+}
+
 func ipToSockaddrIn(ip net.IP) (SockaddrIn, error) {
         ip4 := ip.To4()
         if ip4 == nil {
@@ -78,6 +301,75 @@ func ipToSockaddrIn(ip net.IP) (SockaddrIn, error) {
         return sa, nil
 }
 
+// addrAddInet6 adds the IPv6 address to the link name using
+// SIOCAIFADDR_IN6. Of the flags, IN6_IFF_ANYCAST, IN6_IFF_PREFER_SOURCE,
+// IN6_IFF_NODAD and IN6_IFF_AUTOCONF can be set, in6StateFlags are
+// dropped. Lifetimes of 0 mean infinite unless one of them is set.
+func addrAddInet6(fd int, name string, addr *Addr) error {
+	flags := addr.Flags &^ in6StateFlags
+	if flags&^in6AddFlags != 0 {
+		return fmt.Errorf("flags %#x can not be set on an IPv6 address", flags&^in6AddFlags)
+	}
+
+	var ifra in6Aliasreq
+	copy(ifra.Name[:unix.IFNAMSIZ-1], name)
+	ifra.Addr = ipToSockaddrIn6(addr.IP)
+	mask := addr.Mask
+	if mask == nil {
+		mask = net.CIDRMask(128, 128)
+	}
+	ifra.Prefixmask = ipToSockaddrIn6(net.IP(mask))
+	ifra.Flags = int32(flags)
+	ifra.Vhid = int32(addr.Vhid)
+	ifra.Lifetime.Vltime = ND6_INFINITE_LIFETIME
+	ifra.Lifetime.Pltime = ND6_INFINITE_LIFETIME
+	if addr.ValidLft > 0 || addr.PreferedLft > 0 {
+		ifra.Lifetime.Vltime = uint32(addr.ValidLft)
+		ifra.Lifetime.Pltime = uint32(addr.PreferedLft)
+	}
+
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(SIOCAIFADDR_IN6),
+		uintptr(unsafe.Pointer(&ifra)),
+	)
+	if errno == unix.ENXIO {
+		return LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	if errno != 0 {
+		return fmt.Errorf("ioctl SIOCAIFADDR_IN6 error: %w", errno)
+	}
+	return nil
+}
+
+func ipToSockaddrIn6(ip net.IP) SockaddrIn6 {
+	var sa SockaddrIn6
+	sa.Len = uint8(unsafe.Sizeof(sa))
+	sa.Family = unix.AF_INET6
+	copy(sa.Addr[:], ip.To16())
+	return sa
+}
+
+// addrFlagsInet6 returns the IN6_IFF flags of the IPv6 address of the
+// link name using SIOCGIFAFLAG_IN6.
+func addrFlagsInet6(fd int, name string, ip net.IP) (int, error) {
+	var ifr in6Ifreq
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
+	*(*SockaddrIn6)(unsafe.Pointer(&ifr.Ifru[0])) = ipToSockaddrIn6(ip)
+
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(SIOCGIFAFLAG_IN6),
+		uintptr(unsafe.Pointer(&ifr)),
+	)
+	if errno != 0 {
+		return 0, fmt.Errorf("ioctl SIOCGIFAFLAG_IN6 error: %w", errno)
+	}
+	return int(*(*int32)(unsafe.Pointer(&ifr.Ifru[0]))), nil
+}
+
 // AddrDel will delete an IP address from a link device.
 //
 // Equivalent to: `ip addr del $addr dev $link`
@@ -94,8 +386,91 @@ func AddrDel(link Link, addr *Addr) error {
 // If `addr` is an IPv4 address and the broadcast address is not given, it
 // will be automatically computed based on the IP mask if /30 or larger.
 func (h *Handle) AddrDel(link Link, addr *Addr) error {
-	req := h.newNetlinkRequest(unix.RTM_DELADDR, nlunix.NLM_F_ACK)
-	return h.addrHandle(link, addr, req)
+	return h.AddrDelWithOptions(link, addr, AddrOptions{})
+}
+
+// AddrDelWithOptions works like AddrDel with the options.
+func AddrDelWithOptions(link Link, addr *Addr, options AddrOptions) error {
+	return pkgHandle.AddrDelWithOptions(link, addr, options)
+}
+
+// AddrDelWithOptions works like AddrDel with the options. Without netlink
+// the address is deleted with SIOCDIFADDR or SIOCDIFADDR_IN6.
+func (h *Handle) AddrDelWithOptions(link Link, addr *Addr, options AddrOptions) error {
+	req := h.newNetlinkRequest(nlunix.RTM_DELADDR, nlunix.NLM_F_ACK)
+	err := h.addrHandle(link, addr, req)
+	if netlinkUnsupported(err) && h.inCurrentVnet() {
+		if link == nil {
+			link = &Device{LinkAttrs{Index: addr.LinkIndex}}
+		}
+		var name string
+		if name, err = h.linkName(link); err == nil {
+			err = addrDelIoctl(name, addr.IP)
+		}
+	}
+	if options.Idempotent && addrNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// addrNotFound reports whether err is the error of deleting an address
+// the link does not have.
+func addrNotFound(err error) bool {
+	return errors.Is(err, unix.EADDRNOTAVAIL) || errors.Is(err, unix.ENOENT) || errors.Is(err, unix.ESRCH)
+}
+
+// addrDelIoctl deletes the address from the link name using SIOCDIFADDR
+// or SIOCDIFADDR_IN6.
+func addrDelIoctl(name string, ip net.IP) error {
+	if ip4 := ip.To4(); ip4 != nil {
+		fd, err := getSocketUDP()
+		if err != nil {
+			return fmt.Errorf("socket error: %w", err)
+		}
+		defer unix.Close(fd)
+
+		var ifr IfreqWithSockaddr
+		copy(ifr.Name[:unix.IFNAMSIZ-1], name)
+		sin, _ := ipToSockaddrIn(ip4)
+		*(*SockaddrIn)(unsafe.Pointer(&ifr.Data)) = sin
+		_, _, errno := unix.Syscall(
+			unix.SYS_IOCTL,
+			uintptr(fd),
+			uintptr(unix.SIOCDIFADDR),
+			uintptr(unsafe.Pointer(&ifr)),
+		)
+		if errno == unix.ENXIO {
+			return LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+		}
+		if errno != 0 {
+			return fmt.Errorf("ioctl SIOCDIFADDR error: %w", errno)
+		}
+		return nil
+	}
+
+	fd, err := unix.Socket(unix.AF_INET6, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	var ifr in6Ifreq
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
+	*(*SockaddrIn6)(unsafe.Pointer(&ifr.Ifru[0])) = ipToSockaddrIn6(ip)
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(SIOCDIFADDR_IN6),
+		uintptr(unsafe.Pointer(&ifr)),
+	)
+	if errno == unix.ENXIO {
+		return LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	if errno != 0 {
+		return fmt.Errorf("ioctl SIOCDIFADDR_IN6 error: %w", errno)
+	}
+	return nil
 }
 
 func (h *Handle) addrHandle(link Link, addr *Addr, req *nl.NetlinkRequest) error {
@@ -116,7 +491,7 @@ func (h *Handle) addrHandle(link Link, addr *Addr, req *nl.NetlinkRequest) error
 	if addr.Peer != nil {
 		mask = addr.Peer.Mask
 	}
-	prefixlen, masklen := mask.Size()
+	prefixlen, _ := mask.Size()
 	msg.Prefixlen = uint8(prefixlen)
 	req.AddData(msg)
 
@@ -143,27 +518,16 @@ func (h *Handle) addrHandle(link Link, addr *Addr, req *nl.NetlinkRequest) error
 	addressData := nl.NewRtAttr(nlunix.IFA_ADDRESS, peerAddrData)
 	req.AddData(addressData)
 
-	if addr.Flags != 0 {
-		if addr.Flags <= 0xff {
-			msg.IfAddrmsg.Flags = uint8(addr.Flags)
-		} else {
-			b := make([]byte, 4)
-			native.PutUint32(b, uint32(addr.Flags))
-			flagsData := nl.NewRtAttr(nlunix.IFA_FLAGS, b)
-			req.AddData(flagsData)
-		}
-	}
+	// addr.Flags are not sent: the address is deleted by IFA_LOCAL alone,
+	// and the flags of an IPv6 Addr from AddrList are IN6_IFF flags, not
+	// the IFA_F flags netlink takes.
 
 	if family == FAMILY_V4 {
 		// Automatically set the broadcast address if it is unset and the
 		// subnet is large enough to sensibly have one (/30 or larger).
 		// See: RFC 3021
-		if addr.Broadcast == nil && prefixlen < 31 {
-			calcBroadcast := make(net.IP, masklen/8)
-			for i := range localAddrData {
-				calcBroadcast[i] = localAddrData[i] | ^mask[i]
-			}
-			addr.Broadcast = calcBroadcast
+		if addr.Broadcast == nil {
+			addr.Broadcast = addrBroadcast(localAddrData, mask)
 		}
 
 		if addr.Broadcast != nil {
@@ -237,9 +601,52 @@ func (h *Handle) AddrList(link Link, family int) ([]Addr, error) {
 		res = append(res, addr)
 	}
 
+	h.fillAddrFlagsInet6(res)
 	return res, nil
 }
 
+// fillAddrFlagsInet6 replaces the flags of the IPv6 addresses with all
+// of their IN6_IFF flags. The name of each link is looked up once. Errors
+// are ignored, the flags netlink reported are kept then, as they are for
+// a handle on another vnet than the one SIOCGIFAFLAG_IN6 acts on.
+func (h *Handle) fillAddrFlagsInet6(addrs []Addr) {
+	if !h.inCurrentVnet() {
+		return
+	}
+	fd := -1
+	defer func() {
+		if fd >= 0 {
+			unix.Close(fd)
+		}
+	}()
+	names := make(map[int]string)
+	for i := range addrs {
+		addr := &addrs[i]
+		if addr.IP.To4() != nil {
+			continue
+		}
+		name, ok := names[addr.LinkIndex]
+		if !ok {
+			if link, err := h.linkByIndex(addr.LinkIndex); err == nil {
+				name = link.Attrs().Name
+			}
+			names[addr.LinkIndex] = name
+		}
+		if name == "" {
+			continue
+		}
+		if fd < 0 {
+			var err error
+			if fd, err = unix.Socket(unix.AF_INET6, unix.SOCK_DGRAM, 0); err != nil {
+				return
+			}
+		}
+		if flags, err := addrFlagsInet6(fd, name, addr.IP); err == nil {
+			addr.Flags = flags
+		}
+	}
+}
+
 func parseAddr(m []byte) (addr Addr, family int, err error) {
 	msg := nl.DeserializeIfAddrmsg(m)
 
@@ -284,6 +691,17 @@ func parseAddr(m []byte) (addr Addr, family int, err error) {
 			ci := nl.DeserializeIfaCacheInfo(attr.Value)
 			addr.PreferedLft = int(ci.Prefered)
 			addr.ValidLft = int(ci.Valid)
+		case ifaFreeBSD:
+			nested, err1 := nl.ParseRouteAttr(attr.Value)
+			if err1 != nil {
+				err = err1
+				return
+			}
+			for _, a := range nested {
+				if a.Attr.Type == ifafVhid && len(a.Value) >= 4 {
+					addr.Vhid = int(native.Uint32(a.Value[0:4]))
+				}
+			}
 		}
 	}
 
@@ -306,7 +724,175 @@ func parseAddr(m []byte) (addr Addr, family int, err error) {
 	}
 
 	addr.Scope = int(msg.Scope)
+	if family == FAMILY_V6 {
+		addr.Flags = in6FlagsFromIfa(addr.Flags)
+	}
 
 	return
 }
 
+// FreeBSD specific address attributes, see netlink/route/ifaddrs.h.
+const (
+	ifaFreeBSD = 11 // IFA_FREEBSD, nested IFAF attributes
+	ifafVhid   = 1  // IFAF_VHID
+)
+
+// IFA_F flags netlink reports for IPv6 addresses, see netlink/route/ifaddrs.h.
+const (
+	ifaFTemporary  = 0x01
+	ifaFNodad      = 0x02
+	ifaFDadfailed  = 0x08
+	ifaFDeprecated = 0x20
+	ifaFTentative  = 0x40
+)
+
+// in6FlagsFromIfa translates the IFA_F flags of an IPv6 address to
+// IN6_IFF flags. Netlink does not report all of them, AddrList adds the
+// others from SIOCGIFAFLAG_IN6.
+func in6FlagsFromIfa(flags int) int {
+	var res int
+	for _, f := range []struct{ ifa, in6 int }{
+		{ifaFTemporary, IN6_IFF_TEMPORARY},
+		{ifaFNodad, IN6_IFF_NODAD},
+		{ifaFDadfailed, IN6_IFF_DUPLICATED},
+		{ifaFDeprecated, IN6_IFF_DEPRECATED},
+		{ifaFTentative, IN6_IFF_TENTATIVE},
+	} {
+		if flags&f.ifa != 0 {
+			res |= f.in6
+		}
+	}
+	return res
+}
+
+// AddrSubscribe takes a chan down which notifications will be sent
+// when addresses change. Close the 'done' chan to stop subscription.
+func AddrSubscribe(ch chan<- AddrUpdate, done <-chan struct{}) error {
+	return addrSubscribeAt(vnet.None(), vnet.None(), ch, done, nil, false, 0, nil, false)
+}
+
+// AddrSubscribeAt works like AddrSubscribe plus it allows the caller
+// to choose the network namespace in which to subscribe (ns).
+func AddrSubscribeAt(ns vnet.VjHandle, ch chan<- AddrUpdate, done <-chan struct{}) error {
+	return addrSubscribeAt(ns, vnet.None(), ch, done, nil, false, 0, nil, false)
+}
+
+// AddrSubscribeOptions contains a set of options to use with
+// AddrSubscribeWithOptions.
+type AddrSubscribeOptions struct {
+	Namespace              *vnet.VjHandle
+	ErrorCallback          func(error)
+	ListExisting           bool
+	ReceiveBufferSize      int
+	ReceiveBufferForceSize bool
+	ReceiveTimeout         *unix.Timeval
+}
+
+// AddrSubscribeWithOptions work like AddrSubscribe but enable to
+// provide additional options to modify the behavior. Currently, the
+// namespace can be provided as well as an error callback.
+func AddrSubscribeWithOptions(ch chan<- AddrUpdate, done <-chan struct{}, options AddrSubscribeOptions) error {
+	if options.Namespace == nil {
+		none := vnet.None()
+		options.Namespace = &none
+	}
+	return addrSubscribeAt(*options.Namespace, vnet.None(), ch, done, options.ErrorCallback, options.ListExisting,
+		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize)
+}
+
+func addrSubscribeAt(newNs, curNs vnet.VjHandle, ch chan<- AddrUpdate, done <-chan struct{}, cberr func(error), listExisting bool,
+	rcvbuf int, rcvTimeout *unix.Timeval, rcvbufForce bool) error {
+	s, err := nl.SubscribeAt(newNs, curNs, nlunix.NETLINK_ROUTE, nlunix.RTNLGRP_IPV4_IFADDR, nlunix.RTNLGRP_IPV6_IFADDR)
+	if netlinkUnsupported(err) && !newNs.IsOpen() {
+		return addrSubscribeRouteSocket(ch, done, cberr, listExisting, rcvbuf, rcvTimeout)
+	}
+	if err != nil {
+		return err
+	}
+	if rcvTimeout != nil {
+		if err := s.SetReceiveTimeout(rcvTimeout); err != nil {
+			return err
+		}
+	}
+	if rcvbuf != 0 {
+		err = s.SetReceiveBufferSize(rcvbuf, rcvbufForce)
+		if err != nil {
+			return err
+		}
+	}
+	if done != nil {
+		go func() {
+			<-done
+			s.Close()
+		}()
+	}
+	if listExisting {
+		req := pkgHandle.newNetlinkRequest(nlunix.RTM_GETADDR,
+			nlunix.NLM_F_DUMP)
+		infmsg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+		req.AddData(infmsg)
+		if err := s.Send(req); err != nil {
+			return err
+		}
+	}
+	go func() {
+		defer close(ch)
+		for {
+			msgs, from, err := s.Receive()
+			if err != nil {
+				if cberr != nil {
+					cberr(fmt.Errorf("Receive failed: %v",
+						err))
+				}
+				return
+			}
+			if from.Pid != nl.PidKernel {
+				if cberr != nil {
+					cberr(fmt.Errorf("Wrong sender portid %d, expected %d", from.Pid, nl.PidKernel))
+				}
+				continue
+			}
+			for _, m := range msgs {
+				if m.Header.Type == nlunix.NLMSG_DONE {
+					continue
+				}
+				if m.Header.Type == nlunix.NLMSG_ERROR {
+					error := int32(native.Uint32(m.Data[0:4]))
+					if error == 0 {
+						continue
+					}
+					if cberr != nil {
+						cberr(fmt.Errorf("error message: %v",
+							syscall.Errno(-error)))
+					}
+					continue
+				}
+				msgType := m.Header.Type
+				if msgType != nlunix.RTM_NEWADDR && msgType != nlunix.RTM_DELADDR {
+					if cberr != nil {
+						cberr(fmt.Errorf("bad message type: %d", msgType))
+					}
+					continue
+				}
+
+				addr, _, err := parseAddr(m.Data)
+				if err != nil {
+					if cberr != nil {
+						cberr(fmt.Errorf("could not parse address: %v", err))
+					}
+					continue
+				}
+
+				ch <- AddrUpdate{LinkAddress: *addr.IPNet,
+					LinkIndex:   addr.LinkIndex,
+					NewAddr:     msgType == nlunix.RTM_NEWADDR,
+					Flags:       addr.Flags,
+					Scope:       addr.Scope,
+					PreferedLft: addr.PreferedLft,
+					ValidLft:    addr.ValidLft}
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,181 @@
+package netlink
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"time"
+	"unsafe"
+
+	netroute "golang.org/x/net/route"
+	"golang.org/x/sys/unix"
+)
+
+// addrSubscribeRouteSocket is addrSubscribeAt for the current namespace
+// without netlink. The updates are built from RTM_NEWADDR and RTM_DELADDR
+// routing messages.
+func addrSubscribeRouteSocket(ch chan<- AddrUpdate, done <-chan struct{}, cberr func(error), listExisting bool,
+	rcvbuf int, rcvTimeout *unix.Timeval) error {
+	s, err := openRouteSocket(unix.AF_UNSPEC, done, rcvbuf, rcvTimeout)
+	if err != nil {
+		return err
+	}
+	var existing []AddrUpdate
+	if listExisting {
+		if existing, err = addrUpdatesFromRIB(); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	go func() {
+		defer close(ch)
+		defer s.Close()
+		for _, u := range existing {
+			ch <- u
+		}
+		for {
+			b, err := s.Receive()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				if cberr != nil {
+					cberr(fmt.Errorf("Receive failed: %v", err))
+				}
+				return
+			}
+			if b[3] != unix.RTM_NEWADDR && b[3] != unix.RTM_DELADDR {
+				continue
+			}
+			msgs, err := netroute.ParseRIB(netroute.RIBTypeRoute, b)
+			if err != nil {
+				if cberr != nil {
+					cberr(err)
+				}
+				continue
+			}
+			for _, m := range msgs {
+				ifam, ok := m.(*netroute.InterfaceAddrMessage)
+				if !ok {
+					continue
+				}
+				if update, ok := addrUpdateFromRouteMessage(ifam); ok {
+					ch <- update
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// addrUpdatesFromRIB returns RTM_NEWADDR updates for the IPv4 and IPv6
+// addresses of the NET_RT_IFLIST sysctl.
+func addrUpdatesFromRIB() ([]AddrUpdate, error) {
+	rib, err := netroute.FetchRIB(unix.AF_UNSPEC, netroute.RIBTypeInterface, 0)
+	if err != nil {
+		return nil, fmt.Errorf("sysctl NET_RT_IFLIST error: %w", err)
+	}
+	msgs, err := netroute.ParseRIB(netroute.RIBTypeInterface, rib)
+	if err != nil {
+		return nil, err
+	}
+	var res []AddrUpdate
+	for _, m := range msgs {
+		ifam, ok := m.(*netroute.InterfaceAddrMessage)
+		if !ok {
+			continue
+		}
+		if update, ok := addrUpdateFromRouteMessage(ifam); ok {
+			res = append(res, update)
+		}
+	}
+	return res, nil
+}
+
+// addrUpdateFromRouteMessage returns the update for an RTM_NEWADDR or
+// RTM_DELADDR message, false if it is not about an IPv4 or IPv6 address.
+// Routing messages carry neither flags nor lifetimes, those of a new IPv6
+// address are looked up with SIOCGIFAFLAG_IN6 and SIOCGIFALIFETIME_IN6.
+func addrUpdateFromRouteMessage(ifam *netroute.InterfaceAddrMessage) (AddrUpdate, bool) {
+	update := AddrUpdate{
+		LinkIndex: ifam.Index,
+		NewAddr:   ifam.Type != unix.RTM_DELADDR,
+	}
+	if len(ifam.Addrs) <= unix.RTAX_IFA {
+		return update, false
+	}
+	switch a := ifam.Addrs[unix.RTAX_IFA].(type) {
+	case *netroute.Inet4Addr:
+		update.LinkAddress = net.IPNet{IP: net.IP(a.IP[:]).To16(), Mask: net.CIDRMask(32, 32)}
+		if m, ok := ifam.Addrs[unix.RTAX_NETMASK].(*netroute.Inet4Addr); ok {
+			update.LinkAddress.Mask = net.IPMask(m.IP[:])
+		}
+		update.Scope = int(addrScope(update.LinkAddress.IP))
+	case *netroute.Inet6Addr:
+		update.LinkAddress = net.IPNet{IP: net.IP(a.IP[:]), Mask: net.CIDRMask(128, 128)}
+		if m, ok := ifam.Addrs[unix.RTAX_NETMASK].(*netroute.Inet6Addr); ok {
+			update.LinkAddress.Mask = net.IPMask(m.IP[:])
+		}
+		update.Scope = int(addrScope(update.LinkAddress.IP))
+		if update.NewAddr {
+			fillAddrUpdateInet6(&update)
+		}
+	default:
+		return update, false
+	}
+	return update, true
+}
+
+// fillAddrUpdateInet6 sets the IN6_IFF flags and the remaining lifetimes
+// of the IPv6 address of the update. Errors are ignored, the address may
+// be gone already.
+func fillAddrUpdateInet6(update *AddrUpdate) {
+	name, err := pkgHandle.linkName(&Device{LinkAttrs{Index: update.LinkIndex}})
+	if err != nil {
+		return
+	}
+	fd, err := unix.Socket(unix.AF_INET6, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return
+	}
+	defer unix.Close(fd)
+	if flags, err := addrFlagsInet6(fd, name, update.LinkAddress.IP); err == nil {
+		update.Flags = flags
+	}
+
+	var ifr in6Ifreq
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
+	*(*SockaddrIn6)(unsafe.Pointer(&ifr.Ifru[0])) = ipToSockaddrIn6(update.LinkAddress.IP)
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(SIOCGIFALIFETIME_IN6),
+		uintptr(unsafe.Pointer(&ifr)),
+	)
+	if errno != 0 {
+		return
+	}
+	// the expiration times are wall clock times, 0 if the address does
+	// not expire, as netlink reports it
+	lt := (*in6Addrlifetime)(unsafe.Pointer(&ifr.Ifru[0]))
+	now := time.Now().Unix()
+	remaining := func(expire int64) int {
+		if expire == 0 {
+			return ND6_INFINITE_LIFETIME
+		}
+		return int(max(expire-now, 0))
+	}
+	update.ValidLft = remaining(lt.Expire)
+	update.PreferedLft = remaining(lt.Preferred)
+}
+
+// addrScope returns the scope netlink reports for the address.
+func addrScope(ip net.IP) Scope {
+	switch {
+	case ip.IsLoopback():
+		return SCOPE_HOST
+	case ip.IsLinkLocalUnicast():
+		return SCOPE_LINK
+	}
+	return SCOPE_UNIVERSE
+}
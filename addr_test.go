@@ -0,0 +1,434 @@
+//go:build freebsd
+// +build freebsd
+
+package netlink
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/oss-fun/netlink/nlunix"
+	"golang.org/x/sys/unix"
+)
+
+func TestAddrAddIPv6(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := &Addr{
+		IPNet:       &net.IPNet{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(64, 128)},
+		Flags:       IN6_IFF_NODAD | IN6_IFF_PREFER_SOURCE,
+		ValidLft:    3600,
+		PreferedLft: 1800,
+	}
+	if err := AddrAdd(link, addr); err != nil {
+		t.Fatal(err)
+	}
+
+	addrs, err := AddrList(link, FAMILY_V6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found *Addr
+	for i := range addrs {
+		if addrs[i].IP.Equal(addr.IP) {
+			found = &addrs[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("address %s not found in %v", addr.IP, addrs)
+	}
+	if ones, _ := found.Mask.Size(); ones != 64 {
+		t.Fatalf("unexpected prefix length %d", ones)
+	}
+	if found.Flags&(IN6_IFF_NODAD|IN6_IFF_PREFER_SOURCE) != IN6_IFF_NODAD|IN6_IFF_PREFER_SOURCE {
+		t.Fatalf("unexpected flags %#x", found.Flags)
+	}
+	if found.Flags&IN6_IFF_TENTATIVE != 0 {
+		t.Fatal("address without DAD is tentative")
+	}
+	if found.ValidLft > 3600 || found.ValidLft < 3500 || found.PreferedLft > 1800 || found.PreferedLft < 1700 {
+		t.Fatalf("unexpected lifetimes %d/%d", found.ValidLft, found.PreferedLft)
+	}
+
+	// the flags the kernel maintains are ignored, a listed address can be
+	// added again
+	found.Flags |= IN6_IFF_TENTATIVE | IN6_IFF_DEPRECATED
+	if err := AddrReplace(link, found); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddrReplace(link, &Addr{IPNet: addr.IPNet, Flags: 0x200}); err == nil {
+		t.Fatal("AddrReplace with unknown flag 0x200 succeeded")
+	}
+}
+
+func TestIn6FlagsFromIfa(t *testing.T) {
+	if flags := in6FlagsFromIfa(ifaFTentative | ifaFNodad); flags != IN6_IFF_TENTATIVE|IN6_IFF_NODAD {
+		t.Fatalf("unexpected flags %#x", flags)
+	}
+	if flags := in6FlagsFromIfa(ifaFDadfailed | 0x80); flags != IN6_IFF_DUPLICATED {
+		t.Fatalf("unexpected flags %#x", flags)
+	}
+}
+
+func TestAddrAddBroadcast(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	addr := &Addr{IPNet: &net.IPNet{IP: net.IPv4(192, 168, 10, 1), Mask: net.CIDRMask(24, 32)}}
+	if err := AddrAdd(link, addr); err != nil {
+		t.Fatal(err)
+	}
+	if !addr.Broadcast.Equal(net.IPv4(192, 168, 10, 255)) {
+		t.Fatalf("unexpected broadcast %s", addr.Broadcast)
+	}
+
+	addrs, err := AddrList(link, FAMILY_V4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(addrs) != 1 {
+		t.Fatalf("unexpected addresses %v", addrs)
+	}
+	if !addrs[0].Broadcast.Equal(addr.Broadcast) {
+		t.Fatalf("broadcast %s, want %s", addrs[0].Broadcast, addr.Broadcast)
+	}
+}
+
+func TestAddrBroadcast(t *testing.T) {
+	ip := net.ParseIP("10.1.2.3")
+	if b := addrBroadcast(ip, net.CIDRMask(16, 32)); !b.Equal(net.ParseIP("10.1.255.255")) {
+		t.Fatalf("unexpected broadcast %s", b)
+	}
+	if b := addrBroadcast(ip, net.CIDRMask(30, 32)); !b.Equal(net.ParseIP("10.1.2.3")) {
+		t.Fatalf("unexpected broadcast %s", b)
+	}
+	for _, ones := range []int{31, 32} {
+		if b := addrBroadcast(ip, net.CIDRMask(ones, 32)); b != nil {
+			t.Fatalf("unexpected broadcast %s for /%d", b, ones)
+		}
+	}
+	if b := addrBroadcast(net.ParseIP("2001:db8::1"), net.CIDRMask(64, 128)); b != nil {
+		t.Fatalf("unexpected IPv6 broadcast %s", b)
+	}
+}
+
+func TestAddrReplace(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ipnet := &net.IPNet{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(64, 128)}
+	if err := AddrAdd(link, &Addr{IPNet: ipnet, Flags: IN6_IFF_NODAD, ValidLft: 3600, PreferedLft: 3600}); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddrAdd(link, &Addr{IPNet: ipnet}); !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("adding an existing address returned %v", err)
+	}
+	if err := AddrAddWithOptions(link, &Addr{IPNet: ipnet}, AddrOptions{Idempotent: true}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := AddrReplace(link, &Addr{IPNet: ipnet, Flags: IN6_IFF_NODAD, ValidLft: 600, PreferedLft: 300}); err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := AddrList(link, FAMILY_V6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found *Addr
+	for i := range addrs {
+		if addrs[i].IP.Equal(ipnet.IP) {
+			found = &addrs[i]
+		}
+	}
+	if found == nil {
+		t.Fatalf("address %s not found in %v", ipnet.IP, addrs)
+	}
+	if found.ValidLft > 600 || found.PreferedLft > 300 {
+		t.Fatalf("lifetimes %d/%d were not replaced", found.ValidLft, found.PreferedLft)
+	}
+
+	if err := AddrDel(link, &Addr{IPNet: ipnet}); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddrDel(link, &Addr{IPNet: ipnet}); err == nil {
+		t.Fatal("deleting a missing address succeeded")
+	}
+	if err := AddrDelWithOptions(link, &Addr{IPNet: ipnet}, AddrOptions{Idempotent: true}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAddrDelIoctl(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ipnet := range []*net.IPNet{
+		{IP: net.IPv4(192, 168, 10, 1), Mask: net.CIDRMask(24, 32)},
+		{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(64, 128)},
+	} {
+		if err := AddrAdd(link, &Addr{IPNet: ipnet}); err != nil {
+			t.Fatal(err)
+		}
+		if err := addrDelIoctl("foo", ipnet.IP); err != nil {
+			t.Fatal(err)
+		}
+		if exists, err := addrExists("foo", ipnet.IP); err != nil || exists {
+			t.Fatalf("address %s still exists: %v", ipnet.IP, err)
+		}
+		if err := addrDelIoctl("foo", ipnet.IP); !addrNotFound(err) {
+			t.Fatalf("deleting a missing address returned %v", err)
+		}
+	}
+}
+
+func TestAddrDelNetlink(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, ipnet := range []*net.IPNet{
+		{IP: net.IPv4(192, 168, 10, 1), Mask: net.CIDRMask(24, 32)},
+		{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(64, 128)},
+	} {
+		if err := AddrAdd(link, &Addr{IPNet: ipnet}); err != nil {
+			t.Fatal(err)
+		}
+		// Delete the address as AddrList reports it, with IN6_IFF flags
+		// for IPv6.
+		addrs, err := AddrList(link, FAMILY_ALL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var addr *Addr
+		for i := range addrs {
+			if addrs[i].IP.Equal(ipnet.IP) {
+				addr = &addrs[i]
+			}
+		}
+		if addr == nil {
+			t.Fatalf("address %s not listed", ipnet.IP)
+		}
+		req := pkgHandle.newNetlinkRequest(nlunix.RTM_DELADDR, nlunix.NLM_F_ACK)
+		err = pkgHandle.addrHandle(link, addr, req)
+		if netlinkUnsupported(err) {
+			t.Skip("netlink not supported")
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if exists, err := addrExists("foo", ipnet.IP); err != nil || exists {
+			t.Fatalf("address %s still exists: %v", ipnet.IP, err)
+		}
+		if err := AddrDel(link, addr); !addrNotFound(err) {
+			t.Fatalf("deleting a missing address returned %v", err)
+		}
+		if err := AddrDelWithOptions(link, addr, AddrOptions{Idempotent: true}); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func expectAddrUpdate(ch <-chan AddrUpdate, add bool, addr net.IP) bool {
+	for {
+		timeout := time.After(time.Minute)
+		select {
+		case update := <-ch:
+			if update.NewAddr == add && update.LinkAddress.IP.Equal(addr) {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
+
+func TestAddrSubscribeWithOptions(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	existing := &Addr{IPNet: &net.IPNet{IP: net.IPv4(192, 168, 10, 1), Mask: net.CIDRMask(24, 32)}}
+	if err := AddrAdd(link, existing); err != nil {
+		t.Fatal(err)
+	}
+
+	subscribe := map[string]func(chan<- AddrUpdate, <-chan struct{}) error{
+		"netlink": func(ch chan<- AddrUpdate, done <-chan struct{}) error {
+			return AddrSubscribeWithOptions(ch, done, AddrSubscribeOptions{
+				ListExisting: true,
+				ErrorCallback: func(err error) {
+					t.Log(err)
+				},
+			})
+		},
+		"route socket": func(ch chan<- AddrUpdate, done <-chan struct{}) error {
+			return addrSubscribeRouteSocket(ch, done, nil, true, 0, nil)
+		},
+	}
+	for name, subscribe := range subscribe {
+		t.Run(name, func(t *testing.T) {
+			ch := make(chan AddrUpdate)
+			done := make(chan struct{})
+			defer close(done)
+			if err := subscribe(ch, done); err != nil {
+				t.Fatal(err)
+			}
+			if !expectAddrUpdate(ch, true, existing.IP) {
+				t.Fatal("existing address not listed")
+			}
+
+			addr := &Addr{
+				IPNet:       &net.IPNet{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(64, 128)},
+				Flags:       IN6_IFF_NODAD,
+				ValidLft:    3600,
+				PreferedLft: 1800,
+			}
+			if err := AddrAdd(link, addr); err != nil {
+				t.Fatal(err)
+			}
+			for {
+				var update AddrUpdate
+				select {
+				case update = <-ch:
+				case <-time.After(time.Minute):
+					t.Fatal("Add update not received as expected")
+				}
+				if !update.NewAddr || !update.LinkAddress.IP.Equal(addr.IP) {
+					continue
+				}
+				if update.LinkIndex != link.Attrs().Index {
+					t.Fatalf("unexpected link index %d", update.LinkIndex)
+				}
+				if ones, _ := update.LinkAddress.Mask.Size(); ones != 64 {
+					t.Fatalf("unexpected prefix length %d", ones)
+				}
+				if update.Flags&IN6_IFF_NODAD == 0 {
+					t.Fatalf("unexpected flags %#x", update.Flags)
+				}
+				if update.ValidLft > 3600 || update.PreferedLft > 1800 {
+					t.Fatalf("unexpected lifetimes %d/%d", update.ValidLft, update.PreferedLft)
+				}
+				break
+			}
+
+			if err := AddrDel(link, addr); err != nil {
+				t.Fatal(err)
+			}
+			if !expectAddrUpdate(ch, false, addr.IP) {
+				t.Fatal("Del update not received as expected")
+			}
+		})
+	}
+}
+
+func TestAddrAddAndWait(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	foo, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	bar, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, link := range []Link{foo, bar} {
+		if err := LinkSetUp(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	addr := &Addr{IPNet: &net.IPNet{IP: net.ParseIP("2001:db8::1"), Mask: net.CIDRMask(64, 128)}}
+	if err := AddrAddAndWait(ctx, foo, addr); err != nil {
+		t.Fatal(err)
+	}
+	addrs, err := AddrList(foo, FAMILY_V6)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, a := range addrs {
+		if a.IP.Equal(addr.IP) && a.Flags&(IN6_IFF_TENTATIVE|IN6_IFF_DUPLICATED) != 0 {
+			t.Fatalf("address is not ready, flags %#x", a.Flags)
+		}
+	}
+
+	// the other end of the epair answers duplicate address detection
+	dup := &Addr{IPNet: &net.IPNet{IP: net.ParseIP("2001:db8::2"), Mask: net.CIDRMask(64, 128)}}
+	if err := AddrAdd(bar, &Addr{IPNet: dup.IPNet, Flags: IN6_IFF_NODAD}); err != nil {
+		t.Fatal(err)
+	}
+	err = AddrAddAndWait(ctx, foo, dup)
+	var dupErr ErrDuplicateAddress
+	if !errors.As(err, &dupErr) {
+		t.Fatalf("AddrAddAndWait of a duplicate address returned %v", err)
+	}
+	if dupErr.LinkIndex != foo.Attrs().Index || !dupErr.IP.Equal(dup.IP) {
+		t.Fatalf("unexpected error %v", dupErr)
+	}
+
+	if err := AddrWaitReady(ctx, foo, net.ParseIP("2001:db8::3")); err == nil {
+		t.Fatal("AddrWaitReady of a missing address succeeded")
+	}
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	tentative := &Addr{IPNet: &net.IPNet{IP: net.ParseIP("2001:db8::4"), Mask: net.CIDRMask(64, 128)}}
+	if err := AddrAddAndWait(canceled, foo, tentative); !errors.Is(err, context.Canceled) {
+		t.Fatalf("AddrAddAndWait with a canceled context returned %v", err)
+	}
+}
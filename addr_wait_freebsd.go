@@ -0,0 +1,105 @@
+package netlink
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/oss-fun/vnet"
+	"golang.org/x/sys/unix"
+)
+
+// addrWaitPollInterval is how often AddrWaitReady checks the flags of the
+// address itself, in case a change of the flags is not notified.
+const addrWaitPollInterval = 100 * time.Millisecond
+
+// AddrAddAndWait adds the address like AddrAdd, then waits for it to be
+// ready like AddrWaitReady.
+func AddrAddAndWait(ctx context.Context, link Link, addr *Addr) error {
+	return pkgHandle.AddrAddAndWait(ctx, link, addr)
+}
+
+// AddrAddAndWait adds the address like AddrAdd, then waits for it to be
+// ready like AddrWaitReady.
+func (h *Handle) AddrAddAndWait(ctx context.Context, link Link, addr *Addr) error {
+	if err := h.AddrAdd(link, addr); err != nil {
+		return err
+	}
+	return h.AddrWaitReady(ctx, link, addr.IP)
+}
+
+// AddrWaitReady waits until the address of the link can be used, i.e.
+// until duplicate address detection of an IPv6 address cleared its
+// IN6_IFF_TENTATIVE flag. It returns ErrDuplicateAddress if the address
+// turned out to be IN6_IFF_DUPLICATED and the error of ctx if it is done
+// first. IPv4 addresses are ready at once.
+func AddrWaitReady(ctx context.Context, link Link, ip net.IP) error {
+	return pkgHandle.AddrWaitReady(ctx, link, ip)
+}
+
+// AddrWaitReady waits until the address of the link can be used, i.e.
+// until duplicate address detection of an IPv6 address cleared its
+// IN6_IFF_TENTATIVE flag. It returns ErrDuplicateAddress if the address
+// turned out to be IN6_IFF_DUPLICATED and the error of ctx if it is done
+// first. IPv4 addresses are ready at once.
+func (h *Handle) AddrWaitReady(ctx context.Context, link Link, ip net.IP) error {
+	if ip.To4() != nil {
+		return nil
+	}
+	name, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+	base := *link.Attrs()
+	h.ensureIndex(&base)
+
+	// Subscribe before checking the flags so that no change is missed.
+	// The flags are polled as well, with or without notifications.
+	done := make(chan struct{})
+	ch := make(chan AddrUpdate)
+	if err := addrSubscribeAt(vnet.None(), vnet.None(), ch, done, nil, false, 0, nil, false); err != nil {
+		close(done)
+		ch = nil
+	} else {
+		defer func(ch <-chan AddrUpdate) {
+			close(done)
+			// let the subscription end without blocking on an update
+			go func() {
+				for range ch {
+				}
+			}()
+		}(ch)
+	}
+	ticker := time.NewTicker(addrWaitPollInterval)
+	defer ticker.Stop()
+
+	fd, err := unix.Socket(unix.AF_INET6, unix.SOCK_DGRAM, 0)
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	for {
+		flags, err := addrFlagsInet6(fd, name, ip)
+		if err != nil {
+			return err
+		}
+		if flags&IN6_IFF_DUPLICATED != 0 {
+			return ErrDuplicateAddress{LinkIndex: base.Index, IP: ip}
+		}
+		if flags&IN6_IFF_TENTATIVE == 0 {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case _, ok := <-ch:
+			if !ok {
+				ch = nil
+			}
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,624 @@
+package netlink
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"sync/atomic"
+	"unsafe"
+
+	"github.com/oss-fun/netlink/nl"
+	"github.com/oss-fun/netlink/nlunix"
+	"golang.org/x/sys/unix"
+)
+
+// bridgeIoctl issues the if_bridge command cmd on bridge with the
+// argument struct data of size bytes. Get commands use SIOCGDRVSPEC, set
+// commands SIOCSDRVSPEC.
+func bridgeIoctl(fd int, bridge string, cmd uintptr, data unsafe.Pointer, size uintptr, set bool) error {
+	var ifd ifDrv
+	copy(ifd.Name[:unix.IFNAMSIZ-1], bridge)
+	ifd.Cmd = cmd
+	ifd.Len = size
+	ifd.Data = data
+
+	req, name := uintptr(unix.SIOCGDRVSPEC), "SIOCGDRVSPEC"
+	if set {
+		req, name = uintptr(unix.SIOCSDRVSPEC), "SIOCSDRVSPEC"
+	}
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		req,
+		uintptr(unsafe.Pointer(&ifd)),
+	)
+	if errno != 0 {
+		return fmt.Errorf("ioctl %s error: %w", name, errno)
+	}
+	return nil
+}
+
+// ifBreqProbed is the size of struct ifbreq of the running kernel once
+// ifBreqSize found it.
+var ifBreqProbed atomic.Uintptr
+
+// ifBreqSize returns the size of struct ifbreq of the running kernel,
+// probed on bridge the first time. if_bridge rejects requests of any
+// other size with EINVAL. ifbr_pvid was added during 15-CURRENT, so the
+// release does not tell which size a kernel takes; BRDGGIFFLGS for no
+// member fails with ENOENT instead once the size is right.
+func ifBreqSize(fd int, bridge string) uintptr {
+	if size := ifBreqProbed.Load(); size != 0 {
+		return size
+	}
+	var req ifBreq
+	for _, size := range []uintptr{
+		unsafe.Sizeof(ifBreq{}),
+		unsafe.Offsetof(ifBreq{}.Pvid) + unsafe.Sizeof(ifBreq{}.pad),
+	} {
+		err := bridgeIoctl(fd, bridge, BRDGGIFFLGS, unsafe.Pointer(&req), size, false)
+		if errors.Is(err, unix.ENOENT) {
+			ifBreqProbed.Store(size)
+			return size
+		}
+		if !errors.Is(err, unix.EINVAL) {
+			break
+		}
+	}
+	// bridge is no bridge, the command fails with either size
+	return unsafe.Sizeof(ifBreq{})
+}
+
+// bridgeMemberIoctl issues a set command taking an ifbreq for member.
+func bridgeMemberIoctl(fd int, bridge, member string, cmd uintptr) error {
+	var req ifBreq
+	copy(req.IfsName[:unix.IFNAMSIZ-1], member)
+	return bridgeIoctl(fd, bridge, cmd, unsafe.Pointer(&req), ifBreqSize(fd, bridge), true)
+}
+
+func bridgeSetParam(fd int, bridge string, cmd uintptr, param ifBrparam) error {
+	return bridgeIoctl(fd, bridge, cmd, unsafe.Pointer(&param), unsafe.Sizeof(param), true)
+}
+
+func bridgeGetParam(fd int, bridge string, cmd uintptr) (ifBrparam, error) {
+	var param ifBrparam
+	err := bridgeIoctl(fd, bridge, cmd, unsafe.Pointer(&param), unsafe.Sizeof(param), false)
+	return param, err
+}
+
+// bridgeSetParams applies the if_bridge parameters set in bridge. Timers
+// are converted from centiseconds to seconds. SpanPorts, if not nil,
+// replaces the span ports of the bridge.
+func bridgeSetParams(fd int, bridge *Bridge) error {
+	name := bridge.Attrs().Name
+	var param ifBrparam
+
+	if bridge.AgeingTime != nil {
+		param.setUint32(*bridge.AgeingTime / 100)
+		if err := bridgeSetParam(fd, name, BRDGSTO, param); err != nil {
+			return err
+		}
+	}
+	if bridge.VlanFiltering != nil {
+		flags, err := bridgeGetParam(fd, name, BRDGGFLAGS)
+		if err != nil {
+			return err
+		}
+		if *bridge.VlanFiltering {
+			flags.setUint32(flags.uint32() | IFBRF_VLANFILTER)
+		} else {
+			flags.setUint32(flags.uint32() &^ IFBRF_VLANFILTER)
+		}
+		if err := bridgeSetParam(fd, name, BRDGSFLAGS, flags); err != nil {
+			return err
+		}
+	}
+	if bridge.MaxAddresses != nil {
+		param.setUint32(*bridge.MaxAddresses)
+		if err := bridgeSetParam(fd, name, BRDGSCACHE, param); err != nil {
+			return err
+		}
+	}
+	if bridge.StpProtocol != nil {
+		param.setUint8(uint8(*bridge.StpProtocol))
+		if err := bridgeSetParam(fd, name, BRDGSPROTO, param); err != nil {
+			return err
+		}
+	}
+	if bridge.Priority != nil {
+		param.setUint16(*bridge.Priority)
+		if err := bridgeSetParam(fd, name, BRDGSPRI, param); err != nil {
+			return err
+		}
+	}
+	for _, timer := range []struct {
+		value *uint32
+		cmd   uintptr
+	}{
+		{bridge.HelloTime, BRDGSHT},
+		{bridge.ForwardDelay, BRDGSFD},
+		{bridge.MaxAge, BRDGSMA},
+	} {
+		if timer.value == nil {
+			continue
+		}
+		param.setUint8(uint8(*timer.value / 100))
+		if err := bridgeSetParam(fd, name, timer.cmd, param); err != nil {
+			return err
+		}
+	}
+
+	if bridge.SpanPorts == nil {
+		return nil
+	}
+	members, err := bridgeMembers(fd, name)
+	if err != nil {
+		return err
+	}
+	current := make(map[string]bool)
+	for _, m := range members {
+		if m.IfsFlags&IFBIF_SPAN != 0 {
+			current[nl.BytesToString(m.IfsName[:])] = true
+		}
+	}
+	wanted := make(map[string]bool)
+	for _, port := range bridge.SpanPorts {
+		wanted[port] = true
+		if !current[port] {
+			if err := bridgeMemberIoctl(fd, name, port, BRDGADDS); err != nil {
+				return err
+			}
+		}
+	}
+	for port := range current {
+		if !wanted[port] {
+			if err := bridgeMemberIoctl(fd, name, port, BRDGDELS); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// parseBridgeParams reads the if_bridge parameters of bridge, which are
+// not reported over netlink. Errors are ignored, the fields are left nil
+// then.
+func parseBridgeParams(bridge *Bridge) {
+	fd, err := getSocketUDP()
+	if err != nil {
+		return
+	}
+	defer unix.Close(fd)
+	name := bridge.Attrs().Name
+
+	if param, err := bridgeGetParam(fd, name, BRDGGTO); err == nil {
+		ageingTime := param.uint32() * 100
+		bridge.AgeingTime = &ageingTime
+	}
+	if param, err := bridgeGetParam(fd, name, BRDGGFLAGS); err == nil {
+		vlanFiltering := param.uint32()&IFBRF_VLANFILTER != 0
+		bridge.VlanFiltering = &vlanFiltering
+	}
+	if param, err := bridgeGetParam(fd, name, BRDGGCACHE); err == nil {
+		maxAddresses := param.uint32()
+		bridge.MaxAddresses = &maxAddresses
+	}
+
+	var op ifBropreq
+	if err := bridgeIoctl(fd, name, BRDGPARAM, unsafe.Pointer(&op), unsafe.Sizeof(op), false); err == nil {
+		proto := BridgeStpProtocol(op.Protocol)
+		priority := op.Priority
+		helloTime := uint32(op.HelloTime) * 100
+		forwardDelay := uint32(op.FwdDelay) * 100
+		maxAge := uint32(op.MaxAge) * 100
+		bridge.StpProtocol = &proto
+		bridge.Priority = &priority
+		bridge.HelloTime = &helloTime
+		bridge.ForwardDelay = &forwardDelay
+		bridge.MaxAge = &maxAge
+	}
+
+	if members, err := bridgeMembers(fd, name); err == nil {
+		for _, m := range members {
+			if m.IfsFlags&IFBIF_SPAN != 0 {
+				bridge.SpanPorts = append(bridge.SpanPorts, nl.BytesToString(m.IfsName[:]))
+			}
+		}
+	}
+}
+
+// bridgeMembers returns the members of bridge, including span ports.
+func bridgeMembers(fd int, bridge string) ([]ifBreq, error) {
+	return bridgeConfList[ifBreq](fd, bridge, BRDGGIFS, ifBreqSize(fd, bridge))
+}
+
+// bridgeConfList returns the list of T reported by a get command taking
+// an ifbifconf or ifbaconf. The kernel lays out the entries size bytes
+// apart, which may be less than the size of T, see ifBreqSize.
+func bridgeConfList[T any](fd int, bridge string, cmd uintptr, size uintptr) ([]T, error) {
+	for {
+		// A call without buffer reports the size needed. The kernel
+		// silently truncates the list if entries were added in between,
+		// so retry until it fits with room to spare.
+		var conf ifBifconf
+		if err := bridgeIoctl(fd, bridge, cmd, unsafe.Pointer(&conf), unsafe.Sizeof(conf), false); err != nil {
+			return nil, err
+		}
+		if conf.Len == 0 {
+			return nil, nil
+		}
+		buf := make([]byte, (uintptr(conf.Len)/size+1)*size)
+		conf.Len = uint32(len(buf))
+		conf.Buf = unsafe.Pointer(&buf[0])
+		if err := bridgeIoctl(fd, bridge, cmd, unsafe.Pointer(&conf), unsafe.Sizeof(conf), false); err != nil {
+			return nil, err
+		}
+		n := uintptr(conf.Len) / size
+		if n == uintptr(len(buf))/size {
+			continue
+		}
+		list := make([]T, n)
+		for i := range list {
+			copy(unsafe.Slice((*byte)(unsafe.Pointer(&list[i])), size), buf[uintptr(i)*size:])
+		}
+		return list, nil
+	}
+}
+
+// bridgePort is a bridge member as returned by bridgePorts.
+type bridgePort struct {
+	Bridge      string
+	BridgeIndex int
+	ifBreq
+}
+
+// bridgePorts maps the name of every bridge member, excluding span ports,
+// to its bridge. Bridges are found through the "bridge" interface group
+// if_bridge puts them in.
+func bridgePorts(fd int) (map[string]bridgePort, error) {
+	bridges, err := groupMembers(fd, "bridge")
+	if err != nil {
+		return nil, err
+	}
+	ports := make(map[string]bridgePort)
+	for _, bridge := range bridges {
+		index, err := linkIndexByName(fd, bridge)
+		if err != nil {
+			return nil, err
+		}
+		members, err := bridgeMembers(fd, bridge)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range members {
+			if m.IfsFlags&IFBIF_SPAN == 0 {
+				ports[nl.BytesToString(m.IfsName[:])] = bridgePort{bridge, index, m}
+			}
+		}
+	}
+	return ports, nil
+}
+
+// bridgePortFlags returns the bridge member name with its current flags
+// from BRDGGIFFLGS.
+func bridgePortFlags(fd int, name string) (bridgePort, error) {
+	ports, err := bridgePorts(fd)
+	if err != nil {
+		return bridgePort{}, err
+	}
+	port, ok := ports[name]
+	if !ok {
+		return bridgePort{}, fmt.Errorf("link %s is not a bridge member", name)
+	}
+	err = bridgeIoctl(fd, port.Bridge, BRDGGIFFLGS, unsafe.Pointer(&port.ifBreq), ifBreqSize(fd, port.Bridge), false)
+	return port, err
+}
+
+// bridgeSetPortFlag sets or clears the member flag of the bridge member
+// name with BRDGSIFFLGS.
+func bridgeSetPortFlag(fd int, name string, flag uint32, enable bool) error {
+	port, err := bridgePortFlags(fd, name)
+	if err != nil {
+		return err
+	}
+	if enable {
+		port.IfsFlags |= flag
+	} else {
+		port.IfsFlags &^= flag
+	}
+	return bridgeIoctl(fd, port.Bridge, BRDGSIFFLGS, unsafe.Pointer(&port.ifBreq), ifBreqSize(fd, port.Bridge), true)
+}
+
+// linkIndexByName returns the index of the interface name.
+func linkIndexByName(fd int, name string) (int, error) {
+	var ifr Ifreq
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
+
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(unix.SIOCGIFINDEX),
+		uintptr(unsafe.Pointer(&ifr)),
+	)
+	if errno != 0 {
+		return 0, fmt.Errorf("ioctl SIOCGIFINDEX error: %w", errno)
+	}
+	return int(*(*uint16)(unsafe.Pointer(&ifr.Data))), nil
+}
+
+// fillBridgePortAttrs sets MasterIndex and Protinfo of the given links
+// from their bridge membership, which FreeBSD does not report over
+// netlink. Errors are ignored, the links are left unchanged then.
+func fillBridgePortAttrs(links ...Link) {
+	fd, err := getSocketUDP()
+	if err != nil {
+		return
+	}
+	defer unix.Close(fd)
+
+	ports, err := bridgePorts(fd)
+	if err != nil {
+		return
+	}
+	for _, link := range links {
+		if port, ok := ports[link.Attrs().Name]; ok {
+			protinfo := parseBridgePortFlags(&port.ifBreq)
+			link.Attrs().MasterIndex = port.BridgeIndex
+			link.Attrs().Protinfo = &protinfo
+		}
+	}
+}
+
+// BridgeFdbFlush flushes the address cache of a bridge. Only learned
+// entries are removed unless all is set.
+// Equivalent to: `ifconfig $bridge flush|flushall`
+func BridgeFdbFlush(bridge Link, all bool) error {
+	return pkgHandle.BridgeFdbFlush(bridge, all)
+}
+
+// BridgeFdbFlush flushes the address cache of a bridge. Only learned
+// entries are removed unless all is set.
+// Equivalent to: `ifconfig $bridge flush|flushall`
+func (h *Handle) BridgeFdbFlush(bridge Link, all bool) error {
+	name, err := h.linkName(bridge)
+	if err != nil {
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	var req ifBreq
+	req.IfsFlags = IFBF_FLUSHDYN
+	if all {
+		req.IfsFlags = IFBF_FLUSHALL
+	}
+	return bridgeIoctl(fd, name, BRDGFLUSH, unsafe.Pointer(&req), ifBreqSize(fd, name), true)
+}
+
+// bridgeFdbList returns the address cache of bridge as AF_BRIDGE
+// neighbours. Learned entries are NUD_REACHABLE, static and sticky ones
+// NUD_NOARP, sticky ones are flagged NTF_STICKY in addition.
+func bridgeFdbList(fd int, bridge string, bridgeIndex int) ([]Neigh, error) {
+	entries, err := bridgeConfList[ifBareq](fd, bridge, BRDGRTS, unsafe.Sizeof(ifBareq{}))
+	if err != nil {
+		return nil, err
+	}
+
+	indexes := make(map[string]int)
+	res := make([]Neigh, 0, len(entries))
+	for _, e := range entries {
+		port := nl.BytesToString(e.IfsName[:])
+		index, ok := indexes[port]
+		if !ok {
+			if index, err = linkIndexByName(fd, port); err != nil {
+				// the port left the bridge in between
+				continue
+			}
+			indexes[port] = index
+		}
+
+		neigh := Neigh{
+			LinkIndex:    index,
+			Family:       nlunix.AF_BRIDGE,
+			State:        NUD_REACHABLE,
+			Flags:        NTF_MASTER,
+			HardwareAddr: append(net.HardwareAddr(nil), e.Dst[:]...),
+			Vlan:         int(e.Vlan),
+			MasterIndex:  bridgeIndex,
+		}
+		switch e.Flags & IFBAF_TYPEMASK {
+		case IFBAF_STATIC:
+			neigh.State = NUD_NOARP
+		case IFBAF_STICKY:
+			neigh.State = NUD_NOARP
+			neigh.Flags |= NTF_STICKY
+		}
+		res = append(res, neigh)
+	}
+	return res, nil
+}
+
+// bridgeFdbAdd adds or updates the address cache entry for hwaddr on the
+// member port of bridge.
+func bridgeFdbAdd(fd int, bridge, port string, hwaddr net.HardwareAddr, vlan int, flags uint8) error {
+	var req ifBareq
+	copy(req.IfsName[:unix.IFNAMSIZ-1], port)
+	copy(req.Dst[:], hwaddr)
+	req.Vlan = uint16(vlan)
+	req.Flags = flags
+	return bridgeIoctl(fd, bridge, BRDGSADDR, unsafe.Pointer(&req), unsafe.Sizeof(req), true)
+}
+
+// bridgeFdbDel deletes the address cache entry for hwaddr from bridge.
+func bridgeFdbDel(fd int, bridge string, hwaddr net.HardwareAddr, vlan int) error {
+	var req ifBareq
+	copy(req.Dst[:], hwaddr)
+	req.Vlan = uint16(vlan)
+	return bridgeIoctl(fd, bridge, BRDGDADDR, unsafe.Pointer(&req), unsafe.Sizeof(req), true)
+}
+
+// BridgeVlanList gets a map of device id to bridge vlan infos. The PVID
+// of a member is reported untagged, the other VLANs tagged.
+// Equivalent to: `bridge vlan show`
+func BridgeVlanList() (map[int32][]*nl.BridgeVlanInfo, error) {
+	return pkgHandle.BridgeVlanList()
+}
+
+// BridgeVlanList gets a map of device id to bridge vlan infos. The PVID
+// of a member is reported untagged, the other VLANs tagged.
+// Equivalent to: `bridge vlan show`
+func (h *Handle) BridgeVlanList() (map[int32][]*nl.BridgeVlanInfo, error) {
+	fd, err := getSocketUDP()
+	if err != nil {
+		return nil, fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	ports, err := bridgePorts(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	ret := make(map[int32][]*nl.BridgeVlanInfo)
+	for name, port := range ports {
+		index, err := linkIndexByName(fd, name)
+		if err != nil {
+			return nil, err
+		}
+		if err := bridgeIoctl(fd, port.Bridge, BRDGGIFFLGS, unsafe.Pointer(&port.ifBreq), ifBreqSize(fd, port.Bridge), false); err != nil {
+			return nil, err
+		}
+		var req ifbifVlanReq
+		copy(req.IfName[:unix.IFNAMSIZ-1], name)
+		if err := bridgeIoctl(fd, port.Bridge, BRDGGIFVLANSET, unsafe.Pointer(&req), unsafe.Sizeof(req), false); err != nil {
+			return nil, err
+		}
+
+		var infos []*nl.BridgeVlanInfo
+		if port.Pvid != 0 {
+			infos = append(infos, &nl.BridgeVlanInfo{
+				Flags: nl.BRIDGE_VLAN_INFO_PVID | nl.BRIDGE_VLAN_INFO_UNTAGGED,
+				Vid:   port.Pvid,
+			})
+		}
+		for vid := uint16(1); vid < BRVLAN_SETSIZE; vid++ {
+			if req.has(vid) && vid != port.Pvid {
+				infos = append(infos, &nl.BridgeVlanInfo{Vid: vid})
+			}
+		}
+		if len(infos) > 0 {
+			ret[int32(index)] = infos
+		}
+	}
+	return ret, nil
+}
+
+// BridgeVlanAdd adds a new vlan filter entry
+// Equivalent to: `bridge vlan add dev DEV vid VID [ pvid ] [ untagged ] [ self ] [ master ]`
+func BridgeVlanAdd(link Link, vid uint16, pvid, untagged, self, master bool) error {
+	return pkgHandle.BridgeVlanAdd(link, vid, pvid, untagged, self, master)
+}
+
+// BridgeVlanAdd adds a new vlan filter entry
+// Equivalent to: `bridge vlan add dev DEV vid VID [ pvid ] [ untagged ] [ self ] [ master ]`
+func (h *Handle) BridgeVlanAdd(link Link, vid uint16, pvid, untagged, self, master bool) error {
+	return h.bridgeVlanModify(true, link, vid, vid, pvid, untagged, self, master)
+}
+
+// BridgeVlanAddRange adds a new vlan filter entry
+// Equivalent to: `bridge vlan add dev DEV vid VID-VIDEND [ pvid ] [ untagged ] [ self ] [ master ]`
+func BridgeVlanAddRange(link Link, vid, vidEnd uint16, pvid, untagged, self, master bool) error {
+	return pkgHandle.BridgeVlanAddRange(link, vid, vidEnd, pvid, untagged, self, master)
+}
+
+// BridgeVlanAddRange adds a new vlan filter entry
+// Equivalent to: `bridge vlan add dev DEV vid VID-VIDEND [ pvid ] [ untagged ] [ self ] [ master ]`
+func (h *Handle) BridgeVlanAddRange(link Link, vid, vidEnd uint16, pvid, untagged, self, master bool) error {
+	return h.bridgeVlanModify(true, link, vid, vidEnd, pvid, untagged, self, master)
+}
+
+// BridgeVlanDel deletes a vlan filter entry
+// Equivalent to: `bridge vlan del dev DEV vid VID [ pvid ] [ untagged ] [ self ] [ master ]`
+func BridgeVlanDel(link Link, vid uint16, pvid, untagged, self, master bool) error {
+	return pkgHandle.BridgeVlanDel(link, vid, pvid, untagged, self, master)
+}
+
+// BridgeVlanDel deletes a vlan filter entry
+// Equivalent to: `bridge vlan del dev DEV vid VID [ pvid ] [ untagged ] [ self ] [ master ]`
+func (h *Handle) BridgeVlanDel(link Link, vid uint16, pvid, untagged, self, master bool) error {
+	return h.bridgeVlanModify(false, link, vid, vid, pvid, untagged, self, master)
+}
+
+// BridgeVlanDelRange deletes a vlan filter entry
+// Equivalent to: `bridge vlan del dev DEV vid VID-VIDEND [ pvid ] [ untagged ] [ self ] [ master ]`
+func BridgeVlanDelRange(link Link, vid, vidEnd uint16, pvid, untagged, self, master bool) error {
+	return pkgHandle.BridgeVlanDelRange(link, vid, vidEnd, pvid, untagged, self, master)
+}
+
+// BridgeVlanDelRange deletes a vlan filter entry
+// Equivalent to: `bridge vlan del dev DEV vid VID-VIDEND [ pvid ] [ untagged ] [ self ] [ master ]`
+func (h *Handle) BridgeVlanDelRange(link Link, vid, vidEnd uint16, pvid, untagged, self, master bool) error {
+	return h.bridgeVlanModify(false, link, vid, vidEnd, pvid, untagged, self, master)
+}
+
+// bridgeVlanModify maps the Linux vlan filter entries to if_bridge. The
+// bridge itself has no VLANs, so self is only accepted together with
+// master. A member's PVID is always untagged, so pvid and untagged must
+// be given together and set the PVID with BRDGSIFPVID. Other VLANs are
+// tagged and go into the set of BRDGSIFVLANSET.
+func (h *Handle) bridgeVlanModify(add bool, link Link, vid, vidEnd uint16, pvid, untagged, self, master bool) error {
+	if self && !master {
+		return fmt.Errorf("vlan filter entries on the bridge itself are not supported")
+	}
+	if pvid != untagged {
+		return fmt.Errorf("pvid and untagged must be set together, if_bridge only supports an untagged PVID")
+	}
+	if vid == 0 || vidEnd < vid || vidEnd >= BRVLAN_SETSIZE-1 {
+		return fmt.Errorf("invalid vlan range %d-%d", vid, vidEnd)
+	}
+	if pvid && vidEnd != vid {
+		return fmt.Errorf("pvid cannot be set for a vlan range")
+	}
+
+	name, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	port, err := bridgePortFlags(fd, name)
+	if err != nil {
+		return err
+	}
+
+	if pvid {
+		switch {
+		case add:
+			port.Pvid = vid
+		case port.Pvid == vid:
+			port.Pvid = 0
+		default:
+			return nil
+		}
+		return bridgeIoctl(fd, port.Bridge, BRDGSIFPVID, unsafe.Pointer(&port.ifBreq), ifBreqSize(fd, port.Bridge), true)
+	}
+
+	req := ifbifVlanReq{Op: BRDG_VLAN_OP_DEL}
+	if add {
+		req.Op = BRDG_VLAN_OP_ADD
+	}
+	copy(req.IfName[:unix.IFNAMSIZ-1], name)
+	for v := vid; v <= vidEnd; v++ {
+		req.add(v)
+	}
+	return bridgeIoctl(fd, port.Bridge, BRDGSIFVLANSET, unsafe.Pointer(&req), unsafe.Sizeof(req), true)
+}
@@ -0,0 +1,145 @@
+//go:build freebsd
+// +build freebsd
+
+package netlink
+
+import (
+	"testing"
+	"unsafe"
+
+	"github.com/oss-fun/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+func TestBridgeVlan(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	vlanFiltering := true
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}, VlanFiltering: &vlanFiltering}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(bridge)
+	veth := &Veth{LinkAttrs: LinkAttrs{Name: "bar"}, PeerName: "baz"}
+	if err := LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(veth)
+	if err := LinkSetMaster(veth, bridge); err != nil {
+		t.Fatal(err)
+	}
+	port, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := BridgeVlanAdd(port, 10, true, true, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := BridgeVlanAddRange(port, 20, 22, false, false, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := BridgeVlanAdd(port, 30, true, false, false, true); err == nil {
+		t.Fatal("tagged pvid accepted")
+	}
+
+	vlanMap, err := BridgeVlanList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	infos := vlanMap[int32(port.Attrs().Index)]
+	if len(infos) != 4 {
+		t.Fatalf("expected 4 vlans got %v", infos)
+	}
+	if !infos[0].PortVID() || !infos[0].EngressUntag() || infos[0].Vid != 10 {
+		t.Fatalf("expected untagged pvid 10 got %s", infos[0])
+	}
+	for i, vid := range []uint16{20, 21, 22} {
+		info := infos[i+1]
+		if info.Vid != vid || info.Flags != 0 {
+			t.Fatalf("expected tagged vlan %d got %s", vid, info)
+		}
+	}
+
+	if err := BridgeVlanDel(port, 10, true, true, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if err := BridgeVlanDelRange(port, 20, 21, false, false, false, true); err != nil {
+		t.Fatal(err)
+	}
+	if vlanMap, err = BridgeVlanList(); err != nil {
+		t.Fatal(err)
+	}
+	infos = vlanMap[int32(port.Attrs().Index)]
+	if len(infos) != 1 || infos[0].Vid != 22 || infos[0].Flags&nl.BRIDGE_VLAN_INFO_PVID != 0 {
+		t.Fatalf("expected tagged vlan 22 got %v", infos)
+	}
+
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if vf := link.(*Bridge).VlanFiltering; vf == nil || !*vf {
+		t.Fatal("vlan filtering not enabled")
+	}
+}
+
+func TestBridgeMembers(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(bridge)
+	veth := &Veth{LinkAttrs: LinkAttrs{Name: "bar"}, PeerName: "baz"}
+	if err := LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(veth)
+	if err := LinkSetMaster(veth, bridge); err != nil {
+		t.Fatal(err)
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fd)
+	size := ifBreqSize(fd, "foo")
+	if size != unsafe.Sizeof(ifBreq{}) && size != unsafe.Offsetof(ifBreq{}.Pvid)+unsafe.Sizeof(ifBreq{}.pad) {
+		t.Fatalf("unexpected ifbreq size %d", size)
+	}
+	if ifBreqProbed.Load() != size {
+		t.Fatal("ifbreq size not probed on a bridge")
+	}
+	members, err := bridgeMembers(fd, "foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(members) != 1 || nl.BytesToString(members[0].IfsName[:]) != "bar" {
+		t.Fatalf("expected member bar got %v", members)
+	}
+	if _, err := bridgePortFlags(fd, "bar"); err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().MasterIndex != bridge.Index {
+		t.Fatalf("expected master %d got %d", bridge.Index, link.Attrs().MasterIndex)
+	}
+	pkgHandle.lookupByDump = true
+	link, err = LinkByName("bar")
+	pkgHandle.lookupByDump = false
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().MasterIndex != bridge.Index {
+		t.Fatalf("expected master %d from the dump got %d", bridge.Index, link.Attrs().MasterIndex)
+	}
+}
@@ -2,6 +2,7 @@ package netlink
 
 import (
 	"fmt"
+	"sync/atomic"
 	"time"
 
 	"github.com/oss-fun/netlink/nl"
@@ -21,6 +22,15 @@ var pkgHandle = &Handle{}
 type Handle struct {
 	sockets      map[int]*nl.SocketHandle
 	lookupByDump bool
+	linkCache    atomic.Pointer[LinkCache]
+	foreignVnet  bool
+}
+
+// inCurrentVnet reports whether the handle is on the vnet of the calling
+// process. The ioctls and sysctls used besides netlink only act on that
+// one.
+func (h *Handle) inCurrentVnet() bool {
+	return !h.foreignVnet
 }
 
 // SetSocketTimeout configures timeout for default netlink sockets
@@ -138,7 +148,7 @@ func NewHandleAtFrom(newNs, curNs vnet.VjHandle) (*Handle, error) {
 }
 
 func newHandle(newNs, curNs vnet.VjHandle, nlFamilies ...int) (*Handle, error) {
-	h := &Handle{sockets: map[int]*nl.SocketHandle{}}
+	h := &Handle{sockets: map[int]*nl.SocketHandle{}, foreignVnet: newNs.IsOpen()}
 	fams := nl.SupportedNlFamilies
 	if len(nlFamilies) != 0 {
 		fams = nlFamilies
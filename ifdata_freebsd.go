@@ -0,0 +1,154 @@
+package netlink
+
+import (
+	"fmt"
+	"unsafe"
+
+	netroute "golang.org/x/net/route"
+	"golang.org/x/sys/unix"
+)
+
+// SIOCGIFDATA gets struct if_data of an interface through ifr_data.
+const SIOCGIFDATA = 0x8020692c
+
+// ifData is struct if_data, see net/if.h. unix.IfData still has the
+// FreeBSD 10 layout.
+type ifData struct {
+	Type       uint8
+	Physical   uint8
+	Addrlen    uint8
+	Hdrlen     uint8
+	LinkState  uint8
+	Vhid       uint8
+	Datalen    uint16
+	Mtu        uint32
+	Metric     uint32
+	Baudrate   uint64
+	Ipackets   uint64
+	Ierrors    uint64
+	Opackets   uint64
+	Oerrors    uint64
+	Collisions uint64
+	Ibytes     uint64
+	Obytes     uint64
+	Imcasts    uint64
+	Omcasts    uint64
+	Iqdrops    uint64
+	Oqdrops    uint64
+	Noproto    uint64
+	Hwassist   uint64
+	Epoch      int64
+	Lastchange [2]uint64 // struct timeval, padded to 16 bytes
+}
+
+// ifMsghdrl is the fixed part of struct if_msghdrl returned by
+// NET_RT_IFLISTL. The if_data follows at DataOff.
+type ifMsghdrl struct {
+	Msglen  uint16
+	Version uint8
+	Type    uint8
+	Addrs   int32
+	Flags   int32
+	Index   uint16
+	_       uint16
+	Len     uint16
+	DataOff uint16
+	_       int32
+}
+
+// statistics translates the counters of if_data.
+func (d *ifData) statistics() *LinkStatistics {
+	return &LinkStatistics{
+		RxPackets:   d.Ipackets,
+		TxPackets:   d.Opackets,
+		RxBytes:     d.Ibytes,
+		TxBytes:     d.Obytes,
+		RxErrors:    d.Ierrors,
+		TxErrors:    d.Oerrors,
+		RxDropped:   d.Iqdrops,
+		TxDropped:   d.Oqdrops,
+		Multicast:   d.Imcasts,
+		Collisions:  d.Collisions,
+		RxNohandler: d.Noproto,
+		TxMulticast: d.Omcasts,
+	}
+}
+
+// linkIfData returns the if_data of the links from the NET_RT_IFLISTL
+// sysctl, of all links if index is 0.
+func linkIfData(index int) (map[int]*ifData, error) {
+	rib, err := netroute.FetchRIB(unix.AF_UNSPEC, netroute.RIBType(unix.NET_RT_IFLISTL), index)
+	if err != nil {
+		return nil, fmt.Errorf("sysctl NET_RT_IFLISTL error: %w", err)
+	}
+
+	res := make(map[int]*ifData)
+	hdrLen := int(unsafe.Sizeof(ifMsghdrl{}))
+	for len(rib) >= hdrLen {
+		hdr := (*ifMsghdrl)(unsafe.Pointer(&rib[0]))
+		msgLen := int(hdr.Msglen)
+		if msgLen < hdrLen || msgLen > len(rib) {
+			return nil, fmt.Errorf("invalid routing message length %d", msgLen)
+		}
+		off := int(hdr.DataOff)
+		if hdr.Type == unix.RTM_IFINFO && off+int(unsafe.Sizeof(ifData{})) <= msgLen {
+			data := new(ifData)
+			*data = *(*ifData)(unsafe.Pointer(&rib[off]))
+			res[int(hdr.Index)] = data
+		}
+		rib = rib[msgLen:]
+	}
+	return res, nil
+}
+
+// linkStatsByIndex returns the statistics of the link index from its
+// if_data.
+func linkStatsByIndex(index int) (*LinkStatistics, error) {
+	if index <= 0 {
+		return nil, fmt.Errorf("invalid link index %d", index)
+	}
+	data, err := linkIfData(index)
+	if err != nil {
+		return nil, err
+	}
+	d, ok := data[index]
+	if !ok {
+		return nil, LinkNotFoundError{fmt.Errorf("Link not found")}
+	}
+	return d.statistics(), nil
+}
+
+// linkIfDataByName returns the if_data of the link name using
+// SIOCGIFDATA.
+func linkIfDataByName(fd int, name string) (*ifData, error) {
+	var data ifData
+	var ifr Ifreq
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
+	ifr.Data = uintptr(unsafe.Pointer(&data))
+
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(SIOCGIFDATA),
+		uintptr(unsafe.Pointer(&ifr)),
+	)
+	if errno == unix.ENXIO {
+		return nil, LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	if errno != 0 {
+		return nil, fmt.Errorf("ioctl SIOCGIFDATA error: %w", errno)
+	}
+	return &data, nil
+}
+
+// LinkStatsOnly returns only the statistics of the link index, without
+// the cost of a full link lookup. It is meant for frequent polling.
+func LinkStatsOnly(index int) (*LinkStatistics, error) {
+	return pkgHandle.LinkStatsOnly(index)
+}
+
+// LinkStatsOnly returns only the statistics of the link index, without
+// the cost of a full link lookup. It is meant for frequent polling.
+func (h *Handle) LinkStatsOnly(index int) (*LinkStatistics, error) {
+	return linkStatsByIndex(index)
+}
@@ -1,42 +1,146 @@
 package netlink
 
 import (
+	"math/bits"
 	"syscall"
 	"unsafe"
 
 	"golang.org/x/sys/unix"
 )
 
-// ioctl for statistics.
 const (
-	// ETHTOOL_GSSET_INFO gets string set info
-	ETHTOOL_GSSET_INFO = 0x00000037
-	// SIOCETHTOOL is Ethtool interface
-	SIOCETHTOOL = 0x8946
-	// ETHTOOL_GSTRINGS gets specified string set
-	ETHTOOL_GSTRINGS = 0x0000001b
-	// ETHTOOL_GSTATS gets NIC-specific statistics
-	ETHTOOL_GSTATS = 0x0000001d
+	ETHER_ADDR_LEN = 6
 )
 
-// string set id.
+// ioctl for wireguard, see sys/dev/wg/if_wg.h.
 const (
-	// ETH_SS_TEST is self-test result names, for use with %ETHTOOL_TEST
-	ETH_SS_TEST = iota
-	// ETH_SS_STATS statistic names, for use with %ETHTOOL_GSTATS
-	ETH_SS_STATS
-	// ETH_SS_PRIV_FLAGS are driver private flag names
-	ETH_SS_PRIV_FLAGS
-	// _ETH_SS_NTUPLE_FILTERS is deprecated
-	_ETH_SS_NTUPLE_FILTERS
-	// ETH_SS_FEATURES are device feature names
-	ETH_SS_FEATURES
-	// ETH_SS_RSS_HASH_FUNCS is RSS hush function names
-	ETH_SS_RSS_HASH_FUNCS
+	// SIOCSWG sets the wireguard configuration from a packed nvlist
+	SIOCSWG = 0xc02069d2
+	// SIOCGWG gets the wireguard configuration as a packed nvlist
+	SIOCGWG = 0xc02069d3
 )
 
+// ioctl for interface capabilities not in golang.org/x/sys/unix, see
+// sys/sockio.h.
 const (
-	ETHER_ADDR_LEN = 6
+	// SIOCGIFCAPNV gets the interface capabilities as a packed nvlist
+	SIOCGIFCAPNV = 0xc020695b
+	// SIOCSIFCAPNV sets the interface capabilities from a packed nvlist
+	SIOCSIFCAPNV = 0x8020699b
+)
+
+// ioctl for IPv6 addresses, see netinet6/in6_var.h.
+const (
+	// SIOCAIFADDR_IN6 adds an IPv6 address with struct in6_aliasreq
+	SIOCAIFADDR_IN6 = 0x8088691b
+	// SIOCDIFADDR_IN6 deletes an IPv6 address with struct in6_ifreq
+	SIOCDIFADDR_IN6 = 0x81206919
+	// SIOCGIFAFLAG_IN6 gets the flags of an IPv6 address with struct in6_ifreq
+	SIOCGIFAFLAG_IN6 = 0xc1206949
+	// SIOCGIFALIFETIME_IN6 gets the lifetimes of an IPv6 address with struct in6_ifreq
+	SIOCGIFALIFETIME_IN6 = 0xc1206951
+)
+
+// ND6_INFINITE_LIFETIME is the ia6t_vltime and ia6t_pltime of an address
+// that does not expire.
+const ND6_INFINITE_LIFETIME = 0xffffffff
+
+// Link states of if_data ifi_link_state, see net/if.h.
+const (
+	LINK_STATE_UNKNOWN = 0
+	LINK_STATE_DOWN    = 1
+	LINK_STATE_UP      = 2
+)
+
+// if_bridge commands passed in ifdrv through SIOCGDRVSPEC/SIOCSDRVSPEC,
+// see net/if_bridgevar.h.
+const (
+	// BRDGADD adds a bridge member (ifbreq)
+	BRDGADD = 0
+	// BRDGDEL deletes a bridge member (ifbreq)
+	BRDGDEL = 1
+	// BRDGGIFFLGS gets the member flags (ifbreq)
+	BRDGGIFFLGS = 2
+	// BRDGSIFFLGS sets the member flags (ifbreq)
+	BRDGSIFFLGS = 3
+	// BRDGSCACHE sets the address cache size (ifbrparam)
+	BRDGSCACHE = 4
+	// BRDGGCACHE gets the address cache size (ifbrparam)
+	BRDGGCACHE = 5
+	// BRDGGIFS gets the member list (ifbifconf)
+	BRDGGIFS = 6
+	// BRDGRTS gets the address cache (ifbaconf)
+	BRDGRTS = 7
+	// BRDGSADDR sets a static address (ifbareq)
+	BRDGSADDR = 8
+	// BRDGSTO sets the address cache timeout (ifbrparam)
+	BRDGSTO = 9
+	// BRDGGTO gets the address cache timeout (ifbrparam)
+	BRDGGTO = 10
+	// BRDGDADDR deletes an address (ifbareq)
+	BRDGDADDR = 11
+	// BRDGFLUSH flushes the address cache (ifbreq)
+	BRDGFLUSH = 12
+	// BRDGSPRI sets the STP bridge priority (ifbrparam)
+	BRDGSPRI = 14
+	// BRDGSHT sets the STP hello time (ifbrparam)
+	BRDGSHT = 16
+	// BRDGSFD sets the STP forward delay (ifbrparam)
+	BRDGSFD = 18
+	// BRDGSMA sets the STP max age (ifbrparam)
+	BRDGSMA = 20
+	// BRDGADDS adds a span port (ifbreq)
+	BRDGADDS = 23
+	// BRDGDELS deletes a span port (ifbreq)
+	BRDGDELS = 24
+	// BRDGPARAM gets the STP parameters (ifbropreq)
+	BRDGPARAM = 25
+	// BRDGSPROTO sets the STP protocol (ifbrparam)
+	BRDGSPROTO = 28
+	// BRDGSIFPVID sets the member PVID (ifbreq)
+	BRDGSIFPVID = 31
+	// BRDGSIFVLANSET sets the member tagged VLAN set (ifbif_vlan_req)
+	BRDGSIFVLANSET = 32
+	// BRDGGIFVLANSET gets the member tagged VLAN set (ifbif_vlan_req)
+	BRDGGIFVLANSET = 33
+	// BRDGSFLAGS sets the bridge flags (ifbrparam)
+	BRDGSFLAGS = 34
+	// BRDGGFLAGS gets the bridge flags (ifbrparam)
+	BRDGGFLAGS = 35
+)
+
+// if_bridge flags (ifbrp_flags).
+const (
+	// IFBRF_VLANFILTER enables VLAN filtering
+	IFBRF_VLANFILTER = 0x0001
+)
+
+// BRDGSIFVLANSET operations (bv_op).
+const (
+	BRDG_VLAN_OP_SET = 1
+	BRDG_VLAN_OP_ADD = 2
+	BRDG_VLAN_OP_DEL = 3
+)
+
+// BRVLAN_SETSIZE is the number of VLANs in a VLAN set.
+const BRVLAN_SETSIZE = 4096
+
+// if_bridge member flags (ifbr_ifsflags).
+const (
+	// IFBIF_LEARNING learns source addresses
+	IFBIF_LEARNING = 0x0001
+	// IFBIF_DISCOVER floods packets with unknown destination
+	IFBIF_DISCOVER = 0x0002
+	// IFBIF_STP participates in spanning tree
+	IFBIF_STP = 0x0004
+	// IFBIF_SPAN marks a span port
+	IFBIF_SPAN = 0x0008
+	// IFBIF_STICKY makes learned addresses static
+	IFBIF_STICKY = 0x0010
+	// IFBIF_BSTP_EDGE marks a spanning tree edge port
+	IFBIF_BSTP_EDGE = 0x0020
+	// IFBIF_PRIVATE does not forward to other private ports
+	IFBIF_PRIVATE = 0x0800
 )
 
 // Ifreq is a struct for ioctl ethernet manipulation syscalls.
@@ -63,33 +167,238 @@ type Ifaliasreq struct {
 	ifra_addr      SockaddrIn
 	ifra_broadaddr SockaddrIn
 	ifra_mask      SockaddrIn
-	ifra_vhid      uint8
+	ifra_vhid      int32
+}
+
+// SockaddrIn6 is struct sockaddr_in6.
+type SockaddrIn6 struct {
+	Len      uint8
+	Family   uint8
+	Port     uint16
+	Flowinfo uint32
+	Addr     [16]byte
+	ScopeId  uint32
 }
 
-// ethtoolSset is a string set information
-type ethtoolSset struct {
-	cmd      uint32
-	reserved uint32
-	mask     uint64
-	data     [1]uint32
+// in6Addrlifetime is struct in6_addrlifetime.
+type in6Addrlifetime struct {
+	Expire    int64 // valid lifetime expiration time
+	Preferred int64 // preferred lifetime expiration time
+	Vltime    uint32
+	Pltime    uint32
 }
 
-type ethtoolStats struct {
-	cmd    uint32
-	nStats uint32
-	// Followed by nStats * []uint64.
+// in6Aliasreq is struct in6_aliasreq used by SIOCAIFADDR_IN6.
+type in6Aliasreq struct {
+	Name       [unix.IFNAMSIZ]byte
+	Addr       SockaddrIn6
+	Dstaddr    SockaddrIn6
+	Prefixmask SockaddrIn6
+	Flags      int32
+	Lifetime   in6Addrlifetime
+	Vhid       int32
 }
 
-// newIocltStringSetReq creates request to get interface string set
-func newIocltStringSetReq(linkName string) (*Ifreq, *ethtoolSset) {
-	e := &ethtoolSset{
-		cmd:  ETHTOOL_GSSET_INFO,
-		mask: 1 << ETH_SS_STATS,
-	}
+// in6Ifreq is struct in6_ifreq. The union holds ifru_addr or ifru_flags6
+// at its start and is as large as struct icmp6_ifstat.
+type in6Ifreq struct {
+	Name [unix.IFNAMSIZ]byte
+	Ifru [272]byte
+}
+
+// wgDataIO is struct wg_data_io used by SIOCSWG and SIOCGWG.
+type wgDataIO struct {
+	Name [unix.IFNAMSIZ]byte
+	Data unsafe.Pointer
+	Size uint64
+}
 
-	ifreq := &Ifreq{Data: uintptr(unsafe.Pointer(e))}
-	copy(ifreq.Name[:unix.IFNAMSIZ-1], linkName)
-	return ifreq, e
+// ifreqFlags is struct ifreq with the ifr_flags and ifr_flagshigh
+// members used by SIOCGIFFLAGS and SIOCSIFFLAGS.
+type ifreqFlags struct {
+	Name      [unix.IFNAMSIZ]byte
+	Flags     uint16
+	Flagshigh uint16
+	_         [12]byte
+}
+
+// ifreqCap is struct ifreq with the ifr_reqcap and ifr_curcap members
+// used by SIOCGIFCAP and SIOCSIFCAP.
+type ifreqCap struct {
+	Name   [unix.IFNAMSIZ]byte
+	Reqcap int32
+	Curcap int32
+	_      [8]byte
+}
+
+// ifreqCapNv is struct ifreq with the ifr_cap_nv member used by
+// SIOCGIFCAPNV and SIOCSIFCAPNV.
+type ifreqCapNv struct {
+	Name      [unix.IFNAMSIZ]byte
+	BufLength uint32
+	Length    uint32
+	Buffer    unsafe.Pointer
+}
+
+// ifreqBuffer is struct ifreq with the ifr_buffer member used by
+// SIOCGIFDESCR and SIOCSIFDESCR.
+type ifreqBuffer struct {
+	Name   [unix.IFNAMSIZ]byte
+	Length uint
+	Buffer unsafe.Pointer
+}
+
+// ifCloneReq is struct if_clonereq used by SIOCIFGCLONERS.
+type ifCloneReq struct {
+	Total  int32
+	Count  int32
+	Buffer unsafe.Pointer
+}
+
+// ifMediareq is struct ifmediareq used by SIOCGIFMEDIA and
+// SIOCGIFXMEDIA.
+type ifMediareq struct {
+	Name    [unix.IFNAMSIZ]byte
+	Current int32
+	Mask    int32
+	Status  int32
+	Active  int32
+	Count   int32
+	Ulist   unsafe.Pointer
+}
+
+// ifreqMedia is struct ifreq with the ifr_media member used by
+// SIOCSIFMEDIA.
+type ifreqMedia struct {
+	Name  [unix.IFNAMSIZ]byte
+	Media int32
+	_     [12]byte
+}
+
+// ifDrv is struct ifdrv used by SIOCGDRVSPEC and SIOCSDRVSPEC.
+type ifDrv struct {
+	Name [unix.IFNAMSIZ]byte
+	Cmd  uintptr
+	Len  uintptr
+	Data unsafe.Pointer
+}
+
+// ifBreq is struct ifbreq describing a bridge member. Pvid only exists
+// since FreeBSD 15, the size to pass is ifBreqSize.
+type ifBreq struct {
+	IfsName      [unix.IFNAMSIZ]byte
+	IfsFlags     uint32
+	StpFlags     uint32
+	PathCost     uint32
+	Portno       uint8
+	Priority     uint8
+	Proto        uint8
+	Role         uint8
+	State        uint8
+	AddrCnt      uint32
+	AddrMax      uint32
+	AddrExceeded uint32
+	Pvid         uint16
+	pad          [32]uint8
+}
+
+// if_bridge address flags (ifba_flags).
+const (
+	IFBAF_TYPEMASK = 0x03
+	IFBAF_DYNAMIC  = 0x00
+	IFBAF_STATIC   = 0x01
+	IFBAF_STICKY   = 0x02
+)
+
+// if_bridge flush modes (ifbr_ifsflags of BRDGFLUSH).
+const (
+	IFBF_FLUSHDYN = 0x00
+	IFBF_FLUSHALL = 0x01
+)
+
+// ifBareq is struct ifbareq describing an address cache entry.
+type ifBareq struct {
+	IfsName [unix.IFNAMSIZ]byte
+	Expire  uintptr
+	Flags   uint8
+	Dst     [ETHER_ADDR_LEN]byte
+	Vlan    uint16
+}
+
+// ifbifVlanReq is struct ifbif_vlan_req. Set is a bitset of VLAN IDs in
+// C longs.
+type ifbifVlanReq struct {
+	IfName [unix.IFNAMSIZ]byte
+	Op     uint8
+	Set    [BRVLAN_SETSIZE / bits.UintSize]uint
+}
+
+func (r *ifbifVlanReq) add(vid uint16) {
+	r.Set[int(vid)/bits.UintSize] |= 1 << (uint(vid) % bits.UintSize)
+}
+
+func (r *ifbifVlanReq) has(vid uint16) bool {
+	return r.Set[int(vid)/bits.UintSize]&(1<<(uint(vid)%bits.UintSize)) != 0
+}
+
+// ifBifconf is struct ifbifconf used by BRDGGIFS. struct ifbaconf used by
+// BRDGRTS has the same layout.
+type ifBifconf struct {
+	Len uint32
+	Buf unsafe.Pointer
+}
+
+// ifBrparam is struct ifbrparam, a union of a 32, 16 or 8 bit value.
+type ifBrparam struct {
+	data uint32
+}
+
+func (p *ifBrparam) uint8() uint8   { return *(*uint8)(unsafe.Pointer(&p.data)) }
+func (p *ifBrparam) uint16() uint16 { return *(*uint16)(unsafe.Pointer(&p.data)) }
+func (p *ifBrparam) uint32() uint32 { return p.data }
+
+func (p *ifBrparam) setUint8(v uint8)   { *(*uint8)(unsafe.Pointer(&p.data)) = v }
+func (p *ifBrparam) setUint16(v uint16) { *(*uint16)(unsafe.Pointer(&p.data)) = v }
+func (p *ifBrparam) setUint32(v uint32) { p.data = v }
+
+// ifBropreq is struct ifbropreq returned by BRDGPARAM. Timers are in
+// seconds.
+type ifBropreq struct {
+	HoldCount        uint8
+	MaxAge           uint8
+	HelloTime        uint8
+	FwdDelay         uint8
+	Protocol         uint8
+	Priority         uint16
+	RootPort         uint16
+	RootPathCost     uint32
+	BridgeId         uint64
+	DesignatedRoot   uint64
+	DesignatedBridge uint64
+	LastTcTime       unix.Timeval
+}
+
+// ifGroupreq is struct ifgroupreq with the ifgru_groups member used by
+// SIOCGIFGROUP and SIOCGIFGMEMB.
+type ifGroupreq struct {
+	Name   [unix.IFNAMSIZ]byte
+	Len    uint32
+	Groups unsafe.Pointer
+	_      [unix.IFNAMSIZ - unsafe.Sizeof(uintptr(0))]byte
+}
+
+// ifGroupreqGroup is struct ifgroupreq with the ifgru_group member used
+// by SIOCAIFGROUP and SIOCDIFGROUP.
+type ifGroupreqGroup struct {
+	Name  [unix.IFNAMSIZ]byte
+	Len   uint32
+	_     [unsafe.Sizeof(uintptr(0)) - 4]byte
+	Group [unix.IFNAMSIZ]byte
+}
+
+// ifgReq is struct ifg_req, a group or member name.
+type ifgReq struct {
+	Name [unix.IFNAMSIZ]byte
 }
 
 func getSocketUDP() (int, error) {
@@ -55,6 +55,8 @@ type LinkAttrs struct {
 	GROIPv4MaxSize uint32
 	Vfs            []VfInfo // virtual functions available on link
 	Group          uint32
+	Groups         []string // interface groups, see ifconfig(8)
+	Fib            int      // routing table of the link, see setfib(1)
 	PermHWAddr     net.HardwareAddr
 	Slave          LinkSlave
 }
@@ -210,6 +212,11 @@ type LinkStatistics64 struct {
 	TxWindowErrors    uint64
 	RxCompressed      uint64
 	TxCompressed      uint64
+	RxNohandler       uint64
+	// TxMulticast counts the multicast packets sent, from if_data. It
+	// follows the fields of struct rtnl_link_stats64 rather than
+	// Multicast, so that IFLA_STATS64 decodes into the struct as is.
+	TxMulticast uint64
 }
 
 type LinkXdp struct {
@@ -269,6 +276,16 @@ type Bridge struct {
 	VlanFiltering     *bool
 	VlanDefaultPVID   *uint16
 	GroupFwdMask      *uint16
+
+	// if_bridge parameters without a Linux equivalent. Timers are in
+	// centiseconds like AgeingTime and HelloTime, but if_bridge only has
+	// a granularity of one second.
+	MaxAddresses *uint32 // address cache size
+	StpProtocol  *BridgeStpProtocol
+	Priority     *uint16
+	ForwardDelay *uint32
+	MaxAge       *uint32
+	SpanPorts    []string // names of the span ports
 }
 
 func (bridge *Bridge) Attrs() *LinkAttrs {
@@ -279,6 +296,24 @@ func (bridge *Bridge) Type() string {
 	return "bridge"
 }
 
+// BridgeStpProtocol is the spanning tree protocol run by a bridge.
+type BridgeStpProtocol uint8
+
+const (
+	BRIDGE_STP_PROTO_STP  BridgeStpProtocol = 0
+	BRIDGE_STP_PROTO_RSTP BridgeStpProtocol = 2
+)
+
+func (p BridgeStpProtocol) String() string {
+	switch p {
+	case BRIDGE_STP_PROTO_STP:
+		return "stp"
+	case BRIDGE_STP_PROTO_RSTP:
+		return "rstp"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(p))
+}
+
 // Vlan links have ParentIndex set in their Attrs()
 type Vlan struct {
 	LinkAttrs
@@ -0,0 +1,255 @@
+package netlink
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/oss-fun/netlink/nlunix"
+	"github.com/oss-fun/vnet"
+)
+
+// LinkCache keeps the links of a namespace in memory, kept in sync by a
+// link subscription, so that they can be looked up by name or index
+// without asking the kernel. Lookups are safe for concurrent use. The
+// cache lags behind changes by the time it takes to receive their link
+// message. The links returned are shared and must not be modified.
+type LinkCache struct {
+	mu        sync.RWMutex
+	byIndex   map[int]*linkCacheEntry
+	byName    map[string]int
+	version   uint64
+	callbacks map[int]func(LinkUpdate)
+	nextCb    int
+	err       error
+
+	done      chan struct{}
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+type linkCacheEntry struct {
+	link    Link
+	version uint64
+}
+
+// NewLinkCache lists the existing links and subscribes to their changes
+// with the options. It returns once the existing links are in the cache.
+// ListExisting is implied; the ErrorCallback is called for errors of the
+// subscription. Close the cache to end the subscription.
+func NewLinkCache(options LinkSubscribeOptions) (*LinkCache, error) {
+	if options.Namespace == nil {
+		none := vnet.None()
+		options.Namespace = &none
+	}
+	c := &LinkCache{
+		byIndex:   make(map[int]*linkCacheEntry),
+		byName:    make(map[string]int),
+		callbacks: make(map[int]func(LinkUpdate)),
+		done:      make(chan struct{}),
+		closed:    make(chan struct{}),
+	}
+	cberr := func(err error) {
+		// Close makes the receive fail, which is not an error.
+		select {
+		case <-c.done:
+			return
+		default:
+		}
+		c.mu.Lock()
+		c.err = err
+		c.mu.Unlock()
+		if options.ErrorCallback != nil {
+			options.ErrorCallback(err)
+		}
+	}
+
+	ch := make(chan LinkUpdate)
+	err := linkSubscribeAt(*options.Namespace, vnet.None(), ch, c.done, cberr, true, true,
+		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize)
+	if err != nil {
+		return nil, err
+	}
+
+	listed := make(chan struct{})
+	go func() {
+		defer close(c.closed)
+		for update := range ch {
+			if update.Header.Type == nlunix.NLMSG_DONE {
+				close(listed)
+				continue
+			}
+			c.apply(update)
+		}
+	}()
+
+	select {
+	case <-listed:
+		return c, nil
+	case <-c.closed:
+		c.Close()
+		if err := c.Err(); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("link subscription ended before the links were listed")
+	}
+}
+
+// Close ends the subscription of the cache. Lookups keep returning the
+// links as they were.
+func (c *LinkCache) Close() {
+	c.closeOnce.Do(func() { close(c.done) })
+	<-c.closed
+}
+
+// Err returns the last error of the subscription, if any.
+func (c *LinkCache) Err() error {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.err
+}
+
+func (c *LinkCache) apply(update LinkUpdate) {
+	index := int(update.Index)
+
+	c.mu.Lock()
+	if old, ok := c.byIndex[index]; ok && c.byName[old.link.Attrs().Name] == index {
+		delete(c.byName, old.link.Attrs().Name)
+	}
+	c.version++
+	switch update.Header.Type {
+	case nlunix.RTM_NEWLINK:
+		c.byIndex[index] = &linkCacheEntry{link: update.Link, version: c.version}
+		c.byName[update.Link.Attrs().Name] = index
+	case nlunix.RTM_DELLINK:
+		delete(c.byIndex, index)
+	}
+	callbacks := make([]func(LinkUpdate), 0, len(c.callbacks))
+	for _, cb := range c.callbacks {
+		callbacks = append(callbacks, cb)
+	}
+	c.mu.Unlock()
+
+	for _, cb := range callbacks {
+		cb(update)
+	}
+}
+
+// LinkByName returns the cached link with the name.
+func (c *LinkCache) LinkByName(name string) (Link, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	index, ok := c.byName[name]
+	if !ok {
+		return nil, false
+	}
+	return c.byIndex[index].link, true
+}
+
+// LinkByIndex returns the cached link with the index.
+func (c *LinkCache) LinkByIndex(index int) (Link, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.byIndex[index]
+	if !ok {
+		return nil, false
+	}
+	return e.link, true
+}
+
+// IndexByName returns the index of the cached link with the name.
+func (c *LinkCache) IndexByName(name string) (int, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	index, ok := c.byName[name]
+	return index, ok
+}
+
+// NameByIndex returns the name of the cached link with the index.
+func (c *LinkCache) NameByIndex(index int) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	e, ok := c.byIndex[index]
+	if !ok {
+		return "", false
+	}
+	return e.link.Attrs().Name, true
+}
+
+// LinkList returns the cached links.
+func (c *LinkCache) LinkList() []Link {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	links := make([]Link, 0, len(c.byIndex))
+	for _, e := range c.byIndex {
+		links = append(links, e.link)
+	}
+	return links
+}
+
+// Version returns the number of link updates applied to the cache. It
+// changes whenever the cache does.
+func (c *LinkCache) Version() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.version
+}
+
+// LinkVersion returns the cache version at which the link with the index
+// was last updated, 0 if it is not cached.
+func (c *LinkCache) LinkVersion(index int) uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if e, ok := c.byIndex[index]; ok {
+		return e.version
+	}
+	return 0
+}
+
+// OnChange registers cb to be called with every link update after it is
+// applied to the cache. Callbacks are called one at a time and must not
+// block. The returned function unregisters cb.
+func (c *LinkCache) OnChange(cb func(LinkUpdate)) func() {
+	c.mu.Lock()
+	id := c.nextCb
+	c.nextCb++
+	c.callbacks[id] = cb
+	c.mu.Unlock()
+	return func() {
+		c.mu.Lock()
+		delete(c.callbacks, id)
+		c.mu.Unlock()
+	}
+}
+
+// SetLinkCache makes the package functions look up links in the cache
+// rather than asking the kernel, e.g. to resolve the link type of a
+// neighbor. Links missing from the cache are still looked up in the
+// kernel. Names and indexes of links are always asked from the kernel,
+// as the cache lags behind renames. A nil cache turns this off.
+func SetLinkCache(c *LinkCache) {
+	pkgHandle.SetLinkCache(c)
+}
+
+// SetLinkCache makes the handle look up links in the cache rather than
+// asking the kernel, e.g. to resolve the link type of a neighbor. The
+// cache must be of the namespace of the handle. Links missing from the
+// cache are still looked up in the kernel. Names and indexes of links are
+// always asked from the kernel, as the cache lags behind renames. A nil
+// cache turns this off.
+func (h *Handle) SetLinkCache(c *LinkCache) {
+	h.linkCache.Store(c)
+}
+
+// cachedLinkByIndex returns the link with the index from the link cache
+// of the handle, or from the kernel if it is not cached. Links from the
+// kernel lack the attributes filled in by fillLinkAttrs, which internal
+// lookups of the type do not need. Names must not be taken from it, see
+// linkName.
+func (h *Handle) cachedLinkByIndex(index int) (Link, error) {
+	if c := h.linkCache.Load(); c != nil {
+		if link, ok := c.LinkByIndex(index); ok {
+			return link, nil
+		}
+	}
+	return h.linkByIndex(index)
+}
@@ -0,0 +1,118 @@
+package netlink
+
+import (
+	"math/bits"
+	"strings"
+)
+
+// LinkCapability is a set of interface capabilities, the IFCAP_* bits of
+// FreeBSD net/if.h.
+type LinkCapability uint64
+
+const (
+	IFCAP_RXCSUM LinkCapability = 1 << iota
+	IFCAP_TXCSUM
+	IFCAP_NETCONS
+	IFCAP_VLAN_MTU
+	IFCAP_VLAN_HWTAGGING
+	IFCAP_JUMBO_MTU
+	IFCAP_POLLING
+	IFCAP_VLAN_HWCSUM
+	IFCAP_TSO4
+	IFCAP_TSO6
+	IFCAP_LRO
+	IFCAP_WOL_UCAST
+	IFCAP_WOL_MCAST
+	IFCAP_WOL_MAGIC
+	IFCAP_TOE4
+	IFCAP_TOE6
+	IFCAP_VLAN_HWFILTER
+	IFCAP_NV
+	IFCAP_VLAN_HWTSO
+	IFCAP_LINKSTATE
+	IFCAP_NETMAP
+	IFCAP_RXCSUM_IPV6
+	IFCAP_TXCSUM_IPV6
+	IFCAP_HWSTATS
+	IFCAP_TXRTLMT
+	IFCAP_HWRXTSTMP
+	IFCAP_MEXTPG
+	IFCAP_TXTLS4
+	IFCAP_TXTLS6
+	IFCAP_VXLAN_HWCSUM
+	IFCAP_VXLAN_HWTSO
+	IFCAP_TXTLS_RTLMT
+	IFCAP_RXTLS4
+	IFCAP_RXTLS6
+	IFCAP_IPSEC_OFFLOAD
+
+	IFCAP_NOMAP  = IFCAP_MEXTPG
+	IFCAP_HWCSUM = IFCAP_RXCSUM | IFCAP_TXCSUM
+	IFCAP_TSO    = IFCAP_TSO4 | IFCAP_TSO6
+	IFCAP_WOL    = IFCAP_WOL_UCAST | IFCAP_WOL_MCAST | IFCAP_WOL_MAGIC
+	IFCAP_TOE    = IFCAP_TOE4 | IFCAP_TOE6
+	IFCAP_TXTLS  = IFCAP_TXTLS4 | IFCAP_TXTLS6
+	IFCAP_RXTLS  = IFCAP_RXTLS4 | IFCAP_RXTLS6
+)
+
+// linkCapabilityNames are the capability names as shown by ifconfig(8),
+// indexed by bit.
+var linkCapabilityNames = [...]string{
+	"RXCSUM",
+	"TXCSUM",
+	"NETCONS",
+	"VLAN_MTU",
+	"VLAN_HWTAGGING",
+	"JUMBO_MTU",
+	"POLLING",
+	"VLAN_HWCSUM",
+	"TSO4",
+	"TSO6",
+	"LRO",
+	"WOL_UCAST",
+	"WOL_MCAST",
+	"WOL_MAGIC",
+	"TOE4",
+	"TOE6",
+	"VLAN_HWFILTER",
+	"NV",
+	"VLAN_HWTSO",
+	"LINKSTATE",
+	"NETMAP",
+	"RXCSUM_IPV6",
+	"TXCSUM_IPV6",
+	"HWSTATS",
+	"TXRTLMT",
+	"HWRXTSTMP",
+	"MEXTPG",
+	"TXTLS4",
+	"TXTLS6",
+	"VXLAN_HWCSUM",
+	"VXLAN_HWTSO",
+	"TXTLS_RTLMT",
+	"RXTLS4",
+	"RXTLS6",
+	"IPSEC_OFFLOAD",
+}
+
+// String returns the names of the capabilities in c separated by commas.
+func (c LinkCapability) String() string {
+	var names []string
+	for c != 0 {
+		bit := bits.TrailingZeros64(uint64(c))
+		c &^= 1 << bit
+		if bit < len(linkCapabilityNames) {
+			names = append(names, linkCapabilityNames[bit])
+		} else {
+			names = append(names, "UNKNOWN")
+		}
+	}
+	return strings.Join(names, ",")
+}
+
+// LinkCapabilities are the capabilities a link supports and the subset
+// currently enabled.
+type LinkCapabilities struct {
+	Supported LinkCapability
+	Enabled   LinkCapability
+}
@@ -0,0 +1,198 @@
+package netlink
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/oss-fun/netlink/nv"
+	"golang.org/x/sys/unix"
+)
+
+// ifCapSupportedSuffix marks the supported capabilities in the nvlist of
+// SIOCGIFCAPNV.
+const ifCapSupportedSuffix = "_SUPPORTED"
+
+// LinkGetCapabilities returns the capabilities supported by the link and
+// the ones currently enabled.
+// Equivalent to: `ifconfig -m $link`
+func LinkGetCapabilities(link Link) (*LinkCapabilities, error) {
+	return pkgHandle.LinkGetCapabilities(link)
+}
+
+// LinkGetCapabilities returns the capabilities supported by the link and
+// the ones currently enabled.
+// Equivalent to: `ifconfig -m $link`
+func (h *Handle) LinkGetCapabilities(link Link) (*LinkCapabilities, error) {
+	name, err := h.linkName(link)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return nil, fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	caps, err := linkCapabilities(fd, name)
+	if err != nil {
+		return nil, err
+	}
+	if caps.Supported&IFCAP_NV == 0 {
+		return caps, nil
+	}
+
+	// Capabilities beyond the 32 bits of ifr_curcap are only reported
+	// through the nvlist.
+	nvl, err := linkCapabilitiesNv(fd, name)
+	if err != nil {
+		return nil, err
+	}
+	for bit, capName := range linkCapabilityNames {
+		c := LinkCapability(1) << bit
+		if nvl.Exists(capName + ifCapSupportedSuffix) {
+			caps.Supported |= c
+		}
+		if enabled, ok := nvl.GetBool(capName); ok {
+			caps.Supported |= c
+			if enabled {
+				caps.Enabled |= c
+			} else {
+				caps.Enabled &^= c
+			}
+		}
+	}
+	return caps, nil
+}
+
+// LinkSetCapabilities enables and disables capabilities of the link.
+// Capabilities in both sets are disabled.
+// Equivalent to: `ifconfig $link $enable -$disable`
+func LinkSetCapabilities(link Link, enable, disable LinkCapability) error {
+	return pkgHandle.LinkSetCapabilities(link, enable, disable)
+}
+
+// LinkSetCapabilities enables and disables capabilities of the link.
+// Capabilities in both sets are disabled.
+// Equivalent to: `ifconfig $link $enable -$disable`
+func (h *Handle) LinkSetCapabilities(link Link, enable, disable LinkCapability) error {
+	name, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	caps, err := linkCapabilities(fd, name)
+	if err != nil {
+		return err
+	}
+	if caps.Supported&IFCAP_NV != 0 {
+		return linkSetCapabilitiesNv(fd, name, enable, disable)
+	}
+	if (enable|disable)>>32 != 0 {
+		return fmt.Errorf("capabilities %s of link %s need nvlist support", (enable|disable)&^0xffffffff, name)
+	}
+
+	req := ifreqCap{Reqcap: int32((caps.Enabled | enable) &^ disable)}
+	copy(req.Name[:unix.IFNAMSIZ-1], name)
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(unix.SIOCSIFCAP),
+		uintptr(unsafe.Pointer(&req)),
+	)
+	if errno != 0 {
+		return fmt.Errorf("ioctl SIOCSIFCAP error: %w", errno)
+	}
+	return nil
+}
+
+// linkCapabilities returns the 32 bit capabilities of the link name using
+// SIOCGIFCAP.
+func linkCapabilities(fd int, name string) (*LinkCapabilities, error) {
+	var req ifreqCap
+	copy(req.Name[:unix.IFNAMSIZ-1], name)
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(unix.SIOCGIFCAP),
+		uintptr(unsafe.Pointer(&req)),
+	)
+	if errno == unix.ENXIO {
+		return nil, LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	if errno != 0 {
+		return nil, fmt.Errorf("ioctl SIOCGIFCAP error: %w", errno)
+	}
+	return &LinkCapabilities{
+		Supported: LinkCapability(uint32(req.Reqcap)),
+		Enabled:   LinkCapability(uint32(req.Curcap)),
+	}, nil
+}
+
+// linkCapabilitiesNv returns the capability nvlist of the link name using
+// SIOCGIFCAPNV.
+func linkCapabilitiesNv(fd int, name string) (*nv.List, error) {
+	var req ifreqCapNv
+	copy(req.Name[:unix.IFNAMSIZ-1], name)
+
+	// The kernel reports the packed size in Length if the buffer is too
+	// small.
+	buf := make([]byte, 4096)
+	for {
+		req.BufLength = uint32(len(buf))
+		req.Buffer = unsafe.Pointer(&buf[0])
+		_, _, errno := unix.Syscall(
+			unix.SYS_IOCTL,
+			uintptr(fd),
+			uintptr(SIOCGIFCAPNV),
+			uintptr(unsafe.Pointer(&req)),
+		)
+		if errno == unix.EFBIG && int(req.Length) > len(buf) {
+			buf = make([]byte, req.Length)
+			continue
+		}
+		if errno != 0 {
+			return nil, fmt.Errorf("ioctl SIOCGIFCAPNV error: %w", errno)
+		}
+		break
+	}
+	return nv.Unpack(buf[:req.Length])
+}
+
+// linkSetCapabilitiesNv enables and disables capabilities of the link
+// name using SIOCSIFCAPNV.
+func linkSetCapabilitiesNv(fd int, name string, enable, disable LinkCapability) error {
+	nvl := nv.NewList()
+	for bit, capName := range linkCapabilityNames {
+		c := LinkCapability(1) << bit
+		if (enable|disable)&c != 0 {
+			nvl.AddBool(capName, disable&c == 0)
+		}
+	}
+	buf, err := nvl.Pack()
+	if err != nil {
+		return err
+	}
+
+	var req ifreqCapNv
+	copy(req.Name[:unix.IFNAMSIZ-1], name)
+	req.BufLength = uint32(len(buf))
+	req.Length = uint32(len(buf))
+	req.Buffer = unsafe.Pointer(&buf[0])
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(SIOCSIFCAPNV),
+		uintptr(unsafe.Pointer(&req)),
+	)
+	if errno != 0 {
+		return fmt.Errorf("ioctl SIOCSIFCAPNV error: %w", errno)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+package netlink
+
+// DriverInfo identifies the driver and the device behind a link,
+// like `ethtool -i` does on Linux.
+type DriverInfo struct {
+	Driver          string // driver name, if_dname, e.g. "ixl"
+	Unit            int    // driver unit, if_dunit, -1 if none
+	Desc            string // device description, %desc
+	PnpInfo         string // plug and play identifiers, %pnpinfo
+	Location        string // location on the parent bus, %location
+	Parent          string // parent device, %parent
+	BusInfo         string // bus address from Location, e.g. "pci0:3:0:0"
+	FirmwareVersion string // empty if the driver does not export it
+}
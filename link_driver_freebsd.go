@@ -0,0 +1,118 @@
+package netlink
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/oss-fun/netlink/nl"
+	"golang.org/x/sys/unix"
+)
+
+// IFDATA_DRIVERNAME is the net.link.generic.ifdata.<index> sysctl that
+// returns if_dname followed by if_dunit, see net/if_mib.h.
+const IFDATA_DRIVERNAME = 3
+
+// firmwareSysctls are the dev.<driver>.<unit> sysctls drivers use to
+// export their firmware version.
+var firmwareSysctls = []string{
+	"fw_version",
+	"fw_ver",
+	"firmware_version",
+	"ver.fw_ver",
+	"ver.hwrm_fw_ver",
+}
+
+// LinkDriverInfo returns the driver name and unit of the link and the
+// description, identifiers, location and parent of its device.
+// Equivalent to: `ethtool -i $link`
+func LinkDriverInfo(link Link) (*DriverInfo, error) {
+	return pkgHandle.LinkDriverInfo(link)
+}
+
+// LinkDriverInfo returns the driver name and unit of the link and the
+// description, identifiers, location and parent of its device.
+// Equivalent to: `ethtool -i $link`
+func (h *Handle) LinkDriverInfo(link Link) (*DriverInfo, error) {
+	name, err := h.linkName(link)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return nil, fmt.Errorf("socket error: %w", err)
+	}
+	index, err := linkIndexByName(fd, name)
+	unix.Close(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	drivername, err := linkDriverName(index)
+	if err == unix.ENOENT {
+		return nil, LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sysctl net.link.generic.ifdata.%d.drivername error: %w", index, err)
+	}
+	info := &DriverInfo{}
+	info.Driver, info.Unit = splitDriverName(drivername)
+
+	// Cloned interfaces have no device and so no dev sysctls, these
+	// fields stay empty for them.
+	if info.Unit < 0 {
+		return info, nil
+	}
+	dev := fmt.Sprintf("dev.%s.%d.", info.Driver, info.Unit)
+	info.Desc, _ = unix.Sysctl(dev + "%desc")
+	info.PnpInfo, _ = unix.Sysctl(dev + "%pnpinfo")
+	info.Location, _ = unix.Sysctl(dev + "%location")
+	info.Parent, _ = unix.Sysctl(dev + "%parent")
+	info.BusInfo = deviceBusInfo(info.Location)
+	for _, fw := range firmwareSysctls {
+		if v, err := unix.Sysctl(dev + fw); err == nil && v != "" {
+			info.FirmwareVersion = v
+			break
+		}
+	}
+	return info, nil
+}
+
+// linkDriverName returns if_dname followed by if_dunit of the link index,
+// e.g. "ixl0". Renaming a link does not change it.
+func linkDriverName(index int) (string, error) {
+	buf, err := unix.SysctlRaw("net.link.generic.ifdata", index, IFDATA_DRIVERNAME)
+	if err != nil {
+		return "", err
+	}
+	return nl.BytesToString(append(buf, 0)), nil
+}
+
+// splitDriverName splits the drivername of a link, e.g. "ixl0", into the
+// driver and its unit. The unit is -1 for drivers without units.
+func splitDriverName(s string) (string, int) {
+	i := len(s)
+	for i > 0 && s[i-1] >= '0' && s[i-1] <= '9' {
+		i--
+	}
+	if i > 0 && s[i-1] == '-' && i < len(s) {
+		i--
+	}
+	unit, err := strconv.Atoi(s[i:])
+	if err != nil || i == 0 {
+		return s, -1
+	}
+	return s[:i], unit
+}
+
+// deviceBusInfo returns the bus address of a %location sysctl, the
+// dbsf key of PCI devices or the whole location otherwise.
+func deviceBusInfo(location string) string {
+	for _, kv := range strings.Fields(location) {
+		if v, ok := strings.CutPrefix(kv, "dbsf="); ok {
+			return v
+		}
+	}
+	return location
+}
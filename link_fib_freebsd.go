@@ -0,0 +1,179 @@
+package netlink
+
+import (
+	"fmt"
+	"sync"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// LinkSetFib sets the FIB of the link device, the routing table used for
+// packets received on it. The FIB must be below the net.fibs sysctl.
+// Equivalent to: `ifconfig $link fib $fib`
+func LinkSetFib(link Link, fib int) error {
+	return pkgHandle.LinkSetFib(link, fib)
+}
+
+// LinkSetFib sets the FIB of the link device, the routing table used for
+// packets received on it. The FIB must be below the net.fibs sysctl.
+// Equivalent to: `ifconfig $link fib $fib`
+func (h *Handle) LinkSetFib(link Link, fib int) error {
+	name, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	if err := linkSetFib(fd, name, fib); err != nil {
+		return err
+	}
+	// an explicit FIB is no Vrf membership LinkSetNoMaster undoes
+	if index, err := linkIndexByName(fd, name); err == nil {
+		vrfMembers.Lock()
+		delete(vrfMembers.m, index)
+		vrfMembers.Unlock()
+	}
+	return nil
+}
+
+// vrfMembers are the FIBs of the links put into a Vrf with LinkSetMaster
+// by index. FreeBSD has no vrf device, the FIB alone does not tell a Vrf
+// member from a link given a FIB otherwise, which LinkSetNoMaster must
+// keep.
+var vrfMembers = struct {
+	sync.Mutex
+	m map[int]int
+}{m: make(map[int]int)}
+
+// vrfJoin sets the FIB of the link to the Table of the vrf and records
+// the membership.
+func (h *Handle) vrfJoin(link Link, vrf *Vrf) error {
+	if err := h.LinkSetFib(link, int(vrf.Table)); err != nil {
+		return err
+	}
+	name, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+	index, err := linkIndexByName(fd, name)
+	if err != nil {
+		return err
+	}
+	vrfMembers.Lock()
+	vrfMembers.m[index] = int(vrf.Table)
+	vrfMembers.Unlock()
+	return nil
+}
+
+// vrfLeave resets the FIB of the link name to 0 if it was put into a Vrf
+// and is still in its FIB.
+func vrfLeave(fd int, name string) error {
+	index, err := linkIndexByName(fd, name)
+	if err != nil {
+		return err
+	}
+	vrfMembers.Lock()
+	table, ok := vrfMembers.m[index]
+	delete(vrfMembers.m, index)
+	vrfMembers.Unlock()
+	if !ok {
+		return nil
+	}
+	fib, err := linkFib(fd, name)
+	if err != nil || fib != table {
+		return err
+	}
+	return linkSetFib(fd, name, 0)
+}
+
+// LinkGetFib returns the FIB of the link device.
+// Equivalent to: `ifconfig $link`
+func LinkGetFib(link Link) (int, error) {
+	return pkgHandle.LinkGetFib(link)
+}
+
+// LinkGetFib returns the FIB of the link device.
+// Equivalent to: `ifconfig $link`
+func (h *Handle) LinkGetFib(link Link) (int, error) {
+	name, err := h.linkName(link)
+	if err != nil {
+		return 0, err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return 0, fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	return linkFib(fd, name)
+}
+
+// numFibs returns the number of FIBs, the net.fibs sysctl.
+func numFibs() (int, error) {
+	n, err := unix.SysctlUint32("net.fibs")
+	if err != nil {
+		return 0, fmt.Errorf("sysctl net.fibs error: %w", err)
+	}
+	return int(n), nil
+}
+
+// linkSetFib sets the FIB of the link name using SIOCSIFFIB.
+func linkSetFib(fd int, name string, fib int) error {
+	fibs, err := numFibs()
+	if err != nil {
+		return err
+	}
+	if fib < 0 || fib >= fibs {
+		return fmt.Errorf("fib %d out of range, net.fibs is %d", fib, fibs)
+	}
+
+	var ifr Ifreq
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
+	*(*uint32)(unsafe.Pointer(&ifr.Data)) = uint32(fib)
+
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(unix.SIOCSIFFIB),
+		uintptr(unsafe.Pointer(&ifr)),
+	)
+	if errno == unix.ENXIO {
+		return LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	if errno != 0 {
+		return fmt.Errorf("ioctl SIOCSIFFIB error: %w", errno)
+	}
+	return nil
+}
+
+// linkFib returns the FIB of the link name using SIOCGIFFIB.
+func linkFib(fd int, name string) (int, error) {
+	var ifr Ifreq
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
+
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(unix.SIOCGIFFIB),
+		uintptr(unsafe.Pointer(&ifr)),
+	)
+	if errno == unix.ENXIO {
+		return 0, LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	if errno != 0 {
+		return 0, fmt.Errorf("ioctl SIOCGIFFIB error: %w", errno)
+	}
+	return int(*(*uint32)(unsafe.Pointer(&ifr.Data))), nil
+}
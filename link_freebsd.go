@@ -68,17 +68,25 @@ var macvlanModes = [...]uint32{
 }
 
 func ensureIndex(link *LinkAttrs) {
-	if link != nil && link.Index == 0 {
-		newlink, _ := LinkByName(link.Name)
-		if newlink != nil {
-			link.Index = newlink.Attrs().Index
-		}
-	}
+	pkgHandle.ensureIndex(link)
 }
 
+// ensureIndex sets the index of the link if it is 0. It is looked up with
+// SIOCGIFINDEX in the current vnet rather than in the link cache, which
+// may lag behind a rename.
 func (h *Handle) ensureIndex(link *LinkAttrs) {
 	if link != nil && link.Index == 0 {
-		newlink, _ := h.LinkByName(link.Name)
+		if h.inCurrentVnet() {
+			if fd, err := getSocketUDP(); err == nil {
+				index, err := linkIndexByName(fd, link.Name)
+				unix.Close(fd)
+				if err == nil {
+					link.Index = index
+					return
+				}
+			}
+		}
+		newlink, _ := h.linkByName(link.Name)
 		if newlink != nil {
 			link.Index = newlink.Attrs().Index
 		}
@@ -94,62 +102,173 @@ func LinkSetUp(link Link) error {
 // LinkSetUp enables the link device.
 // Equivalent to: `ip link set $link up`
 func (h *Handle) LinkSetUp(link Link) error {
-	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
+	return h.LinkSetFlags(link, unix.IFF_UP, 0)
+}
+
+// LinkSetDown disables link device.
+// Equivalent to: `ip link set $link down`
+func LinkSetDown(link Link) error {
+	return pkgHandle.LinkSetDown(link)
+}
+
+// LinkSetDown disables link device.
+// Equivalent to: `ip link set $link down`
+func (h *Handle) LinkSetDown(link Link) error {
+	return h.LinkSetFlags(link, 0, unix.IFF_UP)
+}
+
+// LinkSetPromiscOn enables persistent promiscuous mode, IFF_PPROMISC.
+// Equivalent to: `ifconfig $link promisc`
+func LinkSetPromiscOn(link Link) error {
+	return pkgHandle.LinkSetPromiscOn(link)
+}
+
+// LinkSetPromiscOn enables persistent promiscuous mode, IFF_PPROMISC.
+// Equivalent to: `ifconfig $link promisc`
+func (h *Handle) LinkSetPromiscOn(link Link) error {
+	return h.LinkSetFlags(link, unix.IFF_PPROMISC, 0)
+}
+
+// LinkSetPromiscOff disables persistent promiscuous mode. The link stays
+// promiscuous while other consumers such as bpf need it.
+// Equivalent to: `ifconfig $link -promisc`
+func LinkSetPromiscOff(link Link) error {
+	return pkgHandle.LinkSetPromiscOff(link)
+}
+
+// LinkSetPromiscOff disables persistent promiscuous mode. The link stays
+// promiscuous while other consumers such as bpf need it.
+// Equivalent to: `ifconfig $link -promisc`
+func (h *Handle) LinkSetPromiscOff(link Link) error {
+	return h.LinkSetFlags(link, 0, unix.IFF_PPROMISC)
+}
+
+// LinkSetAllmulticastOn enables the reception of all multicast packets.
+// FreeBSD only lets the kernel change IFF_ALLMULTI, as multicast
+// memberships need it, so this returns ErrNotImplemented.
+// Equivalent to: `ip link set $link allmulticast on`
+func LinkSetAllmulticastOn(link Link) error {
+	return pkgHandle.LinkSetAllmulticastOn(link)
+}
+
+// LinkSetAllmulticastOn enables the reception of all multicast packets.
+// FreeBSD only lets the kernel change IFF_ALLMULTI, as multicast
+// memberships need it, so this returns ErrNotImplemented.
+// Equivalent to: `ip link set $link allmulticast on`
+func (h *Handle) LinkSetAllmulticastOn(link Link) error {
+	return ErrNotImplemented
+}
+
+// LinkSetAllmulticastOff disables the reception of all multicast packets.
+// FreeBSD only lets the kernel change IFF_ALLMULTI, as multicast
+// memberships need it, so this returns ErrNotImplemented.
+// Equivalent to: `ip link set $link allmulticast off`
+func LinkSetAllmulticastOff(link Link) error {
+	return pkgHandle.LinkSetAllmulticastOff(link)
+}
+
+// LinkSetAllmulticastOff disables the reception of all multicast packets.
+// FreeBSD only lets the kernel change IFF_ALLMULTI, as multicast
+// memberships need it, so this returns ErrNotImplemented.
+// Equivalent to: `ip link set $link allmulticast off`
+func (h *Handle) LinkSetAllmulticastOff(link Link) error {
+	return ErrNotImplemented
+}
+
+// LinkSetARPOff disables ARP on the link, IFF_NOARP.
+// Equivalent to: `ifconfig $link -arp`
+func LinkSetARPOff(link Link) error {
+	return pkgHandle.LinkSetARPOff(link)
+}
+
+// LinkSetARPOff disables ARP on the link, IFF_NOARP.
+// Equivalent to: `ifconfig $link -arp`
+func (h *Handle) LinkSetARPOff(link Link) error {
+	return h.LinkSetFlags(link, unix.IFF_NOARP, 0)
+}
+
+// LinkSetARPOn enables ARP on the link.
+// Equivalent to: `ifconfig $link arp`
+func LinkSetARPOn(link Link) error {
+	return pkgHandle.LinkSetARPOn(link)
+}
+
+// LinkSetARPOn enables ARP on the link.
+// Equivalent to: `ifconfig $link arp`
+func (h *Handle) LinkSetARPOn(link Link) error {
+	return h.LinkSetFlags(link, 0, unix.IFF_NOARP)
+}
+
+// LinkSetFlags sets and clears IFF_* flags of the link, such as
+// IFF_UP, IFF_PPROMISC, IFF_NOARP, IFF_STATICARP, IFF_MONITOR, IFF_DEBUG
+// and IFF_LINK0-2. Flags in both sets are cleared. Flags in
+// IFF_CANTCHANGE are only changed by the kernel and rejected.
+// Equivalent to: `ifconfig $link $set -$clear`
+func LinkSetFlags(link Link, set, clear uint32) error {
+	return pkgHandle.LinkSetFlags(link, set, clear)
+}
+
+// LinkSetFlags sets and clears IFF_* flags of the link, such as
+// IFF_UP, IFF_PPROMISC, IFF_NOARP, IFF_STATICARP, IFF_MONITOR, IFF_DEBUG
+// and IFF_LINK0-2. Flags in both sets are cleared. Flags in
+// IFF_CANTCHANGE are only changed by the kernel and rejected.
+// Equivalent to: `ifconfig $link $set -$clear`
+func (h *Handle) LinkSetFlags(link Link, set, clear uint32) error {
+	name, err := h.linkName(link)
 	if err != nil {
-		return fmt.Errorf("socket error: %v", err)
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
 	}
 	defer unix.Close(fd)
 
-	var ifr Ifreq
-	copy(ifr.Name[:], link.Attrs().Name)
+	flags, err := linkRawFlags(fd, name)
+	if err != nil {
+		return err
+	}
+	newFlags := (flags | set) &^ clear
+	if changed := (flags ^ newFlags) & unix.IFF_CANTCHANGE; changed != 0 {
+		return fmt.Errorf("flags %#x of link %s can only be changed by the kernel", changed, name)
+	}
+	if newFlags == flags {
+		return nil
+	}
 
+	ifr := ifreqFlags{Flags: uint16(newFlags), Flagshigh: uint16(newFlags >> 16)}
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
 	_, _, errno := unix.Syscall(
 		unix.SYS_IOCTL,
 		uintptr(fd),
-		uintptr(unix.SIOCGIFFLAGS),
+		uintptr(unix.SIOCSIFFLAGS),
 		uintptr(unsafe.Pointer(&ifr)),
 	)
 	if errno != 0 {
-		return fmt.Errorf("ioctl SIOCGIFFLAGS error: %v", err)
+		return fmt.Errorf("ioctl SIOCSIFFLAGS error: %w", errno)
 	}
+	return nil
+}
 
-	flags := *(*uint16)(unsafe.Pointer(&ifr.Data))
-	flags |= unix.IFF_UP
-	*(*uint16)(unsafe.Pointer(&ifr.Data)) = flags
-
-	_, _, errno = unix.Syscall(
+// linkRawFlags returns the 32 bit flag word of the link name, ifr_flags
+// and ifr_flagshigh of SIOCGIFFLAGS.
+func linkRawFlags(fd int, name string) (uint32, error) {
+	var ifr ifreqFlags
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
+	_, _, errno := unix.Syscall(
 		unix.SYS_IOCTL,
 		uintptr(fd),
-		uintptr(unix.SIOCSIFFLAGS),
+		uintptr(unix.SIOCGIFFLAGS),
 		uintptr(unsafe.Pointer(&ifr)),
 	)
+	if errno == unix.ENXIO {
+		return 0, LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
 	if errno != 0 {
-		return fmt.Errorf("ioctl SIOCSIFFLAGS error: %v", errno)
+		return 0, fmt.Errorf("ioctl SIOCGIFFLAGS error: %w", errno)
 	}
-
-	return nil
-}
-
-// LinkSetDown disables link device.
-// Equivalent to: `ip link set $link down`
-func LinkSetDown(link Link) error {
-	return pkgHandle.LinkSetDown(link)
-}
-
-// LinkSetDown disables link device.
-// Equivalent to: `ip link set $link down`
-func (h *Handle) LinkSetDown(link Link) error {
-	base := link.Attrs()
-	h.ensureIndex(base)
-	req := h.newNetlinkRequest(nlunix.RTM_NEWLINK, nlunix.NLM_F_ACK)
-
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Change = unix.IFF_UP
-	msg.Index = int32(base.Index)
-	req.AddData(msg)
-
-	_, err := req.Execute(nlunix.NETLINK_ROUTE, 0)
-	return err
+	return uint32(ifr.Flags) | uint32(ifr.Flagshigh)<<16, nil
 }
 
 // LinkSetMTU sets the mtu of the link device.
@@ -216,7 +335,7 @@ func (h *Handle) LinkSetName(link Link, name string) error {
 	)
 
 	if errno != 0 {
-		return fmt.Errorf("ioctl failed: %v.\n", errno)
+		return fmt.Errorf("ioctl failed: %w.\n", errno)
 	}
 	return nil
 }
@@ -256,6 +375,104 @@ func (h *Handle) LinkSetHardwareAddr(link Link, hwaddr net.HardwareAddr) error {
 	return nil
 }
 
+// LinkSetAlias sets the description of the link device. An empty alias
+// removes the description. The alias including its terminating NUL may
+// not exceed the net.ifdescr_maxlen sysctl.
+// Equivalent to: `ifconfig $link description $name`
+func LinkSetAlias(link Link, name string) error {
+	return pkgHandle.LinkSetAlias(link, name)
+}
+
+// LinkSetAlias sets the description of the link device. An empty alias
+// removes the description. The alias including its terminating NUL may
+// not exceed the net.ifdescr_maxlen sysctl.
+// Equivalent to: `ifconfig $link description $name`
+func (h *Handle) LinkSetAlias(link Link, name string) error {
+	ifname, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	return linkSetDescription(fd, ifname, name)
+}
+
+// linkDescrMaxLen is the default of the net.ifdescr_maxlen sysctl.
+const linkDescrMaxLen = 1024
+
+// linkSetDescription sets the description of the link ifname using
+// SIOCSIFDESCR.
+func linkSetDescription(fd int, ifname, descr string) error {
+	maxLen, err := unix.SysctlUint32("net.ifdescr_maxlen")
+	if err != nil {
+		maxLen = linkDescrMaxLen
+	}
+	if len(descr)+1 > int(maxLen) {
+		return fmt.Errorf("alias of %d bytes exceeds the limit of %d", len(descr), maxLen-1)
+	}
+
+	var ifr ifreqBuffer
+	copy(ifr.Name[:unix.IFNAMSIZ-1], ifname)
+	if descr != "" {
+		buf := append([]byte(descr), 0)
+		ifr.Length = uint(len(buf))
+		ifr.Buffer = unsafe.Pointer(&buf[0])
+	}
+
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(unix.SIOCSIFDESCR),
+		uintptr(unsafe.Pointer(&ifr)),
+	)
+	if errno == unix.ENXIO {
+		return LinkNotFoundError{fmt.Errorf("Link %s not found", ifname)}
+	}
+	if errno != 0 {
+		return fmt.Errorf("ioctl SIOCSIFDESCR error: %w", errno)
+	}
+	return nil
+}
+
+// linkDescription returns the description of the link ifname using
+// SIOCGIFDESCR, or an empty string if it has none.
+func linkDescription(fd int, ifname string) (string, error) {
+	var ifr ifreqBuffer
+	copy(ifr.Name[:unix.IFNAMSIZ-1], ifname)
+
+	// The kernel reports the needed length and leaves the buffer unset
+	// if it is too small.
+	buf := make([]byte, 64)
+	for {
+		ifr.Length = uint(len(buf))
+		ifr.Buffer = unsafe.Pointer(&buf[0])
+		_, _, errno := unix.Syscall(
+			unix.SYS_IOCTL,
+			uintptr(fd),
+			uintptr(unix.SIOCGIFDESCR),
+			uintptr(unsafe.Pointer(&ifr)),
+		)
+		if errno == unix.ENOMSG {
+			return "", nil
+		}
+		if errno == unix.ENXIO {
+			return "", LinkNotFoundError{fmt.Errorf("Link %s not found", ifname)}
+		}
+		if errno != 0 {
+			return "", fmt.Errorf("ioctl SIOCGIFDESCR error: %w", errno)
+		}
+		if ifr.Buffer != nil {
+			return nl.BytesToString(buf[:ifr.Length]), nil
+		}
+		buf = make([]byte, ifr.Length)
+	}
+}
+
 func byteToInt(b []byte) []int8 {
 	result := make([]int8, len(b))
 	for i, v := range b {
@@ -265,31 +482,208 @@ func byteToInt(b []byte) []int8 {
 }
 
 
+// LinkSetMaster sets the master of the link device.
+// Equivalent to: `ip link set $link master $master`
+//
+// A *Vrf master has no device, the FIB of the link is set to its Table
+// instead.
+func LinkSetMaster(link Link, master Link) error {
+	return pkgHandle.LinkSetMaster(link, master)
+}
+
+// LinkSetMaster sets the master of the link device.
+// Equivalent to: `ip link set $link master $master`
+//
+// A *Vrf master has no device, the FIB of the link is set to its Table
+// instead.
+func (h *Handle) LinkSetMaster(link Link, master Link) error {
+	if vrf, ok := master.(*Vrf); ok {
+		return h.vrfJoin(link, vrf)
+	}
+	index := 0
+	if master != nil {
+		masterBase := master.Attrs()
+		h.ensureIndex(masterBase)
+		index = masterBase.Index
+	}
+	if index <= 0 {
+		return fmt.Errorf("Device does not exist")
+	}
+	return h.LinkSetMasterByIndex(link, index)
+}
+
+// LinkSetNoMaster removes the master of the link device.
+// Equivalent to: `ip link set $link nomaster`
+//
+// A link put into a Vrf with LinkSetMaster gets its FIB reset to 0, a FIB
+// set with LinkSetFib is kept.
+func LinkSetNoMaster(link Link) error {
+	return pkgHandle.LinkSetNoMaster(link)
+}
+
+// LinkSetNoMaster removes the master of the link device.
+// Equivalent to: `ip link set $link nomaster`
+//
+// A link put into a Vrf with LinkSetMaster gets its FIB reset to 0, a FIB
+// set with LinkSetFib is kept.
+func (h *Handle) LinkSetNoMaster(link Link) error {
+	return h.LinkSetMasterByIndex(link, 0)
+}
+
 // LinkSetMasterByIndex sets the master of the link device.
 // Equivalent to: `ip link set $link master $master`
+//
+// The master must be a bridge, the link is added as a member with
+// BRDGADD. A masterIndex of 0 removes the link from its current bridge
+// with BRDGDEL, or, if it is in no bridge but was put into a Vrf with
+// LinkSetMaster, resets its FIB to 0.
 func LinkSetMasterByIndex(link Link, masterIndex int) error {
 	return pkgHandle.LinkSetMasterByIndex(link, masterIndex)
 }
 
 // LinkSetMasterByIndex sets the master of the link device.
 // Equivalent to: `ip link set $link master $master`
+//
+// The master must be a bridge, the link is added as a member with
+// BRDGADD. A masterIndex of 0 removes the link from its current bridge
+// with BRDGDEL, or, if it is in no bridge but was put into a Vrf with
+// LinkSetMaster, resets its FIB to 0.
 func (h *Handle) LinkSetMasterByIndex(link Link, masterIndex int) error {
+	name, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	if masterIndex == 0 {
+		ports, err := bridgePorts(fd)
+		if err != nil {
+			return err
+		}
+		if port, ok := ports[name]; ok {
+			return bridgeMemberIoctl(fd, port.Bridge, name, BRDGDEL)
+		}
+		return vrfLeave(fd, name)
+	}
+
+	master, err := h.LinkByIndex(masterIndex)
+	if err != nil {
+		return err
+	}
+	if _, ok := master.(*Bridge); !ok {
+		return fmt.Errorf("master %s is not a bridge", master.Attrs().Name)
+	}
+	return bridgeMemberIoctl(fd, master.Attrs().Name, name, BRDGADD)
+}
+
+// linkName returns the name of link, looking it up by index if unset.
+// The name is asked from the kernel rather than the link cache, which
+// lags behind renames, as ioctls address the link by name.
+func (h *Handle) linkName(link Link) (string, error) {
 	base := link.Attrs()
-	h.ensureIndex(base)
-	req := h.newNetlinkRequest(nlunix.RTM_SETLINK, nlunix.NLM_F_ACK)
+	if base.Name != "" {
+		return base.Name, nil
+	}
+	if base.Index == 0 {
+		return "", fmt.Errorf("either LinkAttrs.Name or LinkAttrs.Index must be set")
+	}
+	l, err := h.linkByIndex(base.Index)
+	if err != nil {
+		return "", err
+	}
+	return l.Attrs().Name, nil
+}
 
-	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
-	msg.Index = int32(base.Index)
-	req.AddData(msg)
+// LinkSetLearning sets IFBIF_LEARNING of a bridge member.
+// Equivalent to: `ip link set $link type bridge_slave learning on|off`
+func LinkSetLearning(link Link, mode bool) error {
+	return pkgHandle.LinkSetLearning(link, mode)
+}
 
-	b := make([]byte, 4)
-	native.PutUint32(b, uint32(masterIndex))
+// LinkSetLearning sets IFBIF_LEARNING of a bridge member.
+// Equivalent to: `ip link set $link type bridge_slave learning on|off`
+func (h *Handle) LinkSetLearning(link Link, mode bool) error {
+	return h.setBridgePortFlag(link, mode, IFBIF_LEARNING)
+}
 
-	data := nl.NewRtAttr(nlunix.IFLA_MASTER, b)
-	req.AddData(data)
+// LinkSetFlood sets IFBIF_DISCOVER of a bridge member.
+// Equivalent to: `ip link set $link type bridge_slave flood on|off`
+func LinkSetFlood(link Link, mode bool) error {
+	return pkgHandle.LinkSetFlood(link, mode)
+}
 
-	_, err := req.Execute(nlunix.NETLINK_ROUTE, 0)
-	return err
+// LinkSetFlood sets IFBIF_DISCOVER of a bridge member.
+// Equivalent to: `ip link set $link type bridge_slave flood on|off`
+func (h *Handle) LinkSetFlood(link Link, mode bool) error {
+	return h.setBridgePortFlag(link, mode, IFBIF_DISCOVER)
+}
+
+// LinkSetIsolated sets IFBIF_PRIVATE of a bridge member.
+// Equivalent to: `ip link set $link type bridge_slave isolated on|off`
+func LinkSetIsolated(link Link, mode bool) error {
+	return pkgHandle.LinkSetIsolated(link, mode)
+}
+
+// LinkSetIsolated sets IFBIF_PRIVATE of a bridge member.
+// Equivalent to: `ip link set $link type bridge_slave isolated on|off`
+func (h *Handle) LinkSetIsolated(link Link, mode bool) error {
+	return h.setBridgePortFlag(link, mode, IFBIF_PRIVATE)
+}
+
+// LinkSetBrStp sets IFBIF_STP of a bridge member.
+// Equivalent to: `ifconfig $bridge stp|-stp $link`
+func LinkSetBrStp(link Link, mode bool) error {
+	return pkgHandle.LinkSetBrStp(link, mode)
+}
+
+// LinkSetBrStp sets IFBIF_STP of a bridge member.
+// Equivalent to: `ifconfig $bridge stp|-stp $link`
+func (h *Handle) LinkSetBrStp(link Link, mode bool) error {
+	return h.setBridgePortFlag(link, mode, IFBIF_STP)
+}
+
+// LinkSetBrEdge sets IFBIF_BSTP_EDGE of a bridge member.
+// Equivalent to: `ifconfig $bridge edge|-edge $link`
+func LinkSetBrEdge(link Link, mode bool) error {
+	return pkgHandle.LinkSetBrEdge(link, mode)
+}
+
+// LinkSetBrEdge sets IFBIF_BSTP_EDGE of a bridge member.
+// Equivalent to: `ifconfig $bridge edge|-edge $link`
+func (h *Handle) LinkSetBrEdge(link Link, mode bool) error {
+	return h.setBridgePortFlag(link, mode, IFBIF_BSTP_EDGE)
+}
+
+// LinkSetBrSticky sets IFBIF_STICKY of a bridge member.
+// Equivalent to: `ifconfig $bridge sticky|-sticky $link`
+func LinkSetBrSticky(link Link, mode bool) error {
+	return pkgHandle.LinkSetBrSticky(link, mode)
+}
+
+// LinkSetBrSticky sets IFBIF_STICKY of a bridge member.
+// Equivalent to: `ifconfig $bridge sticky|-sticky $link`
+func (h *Handle) LinkSetBrSticky(link Link, mode bool) error {
+	return h.setBridgePortFlag(link, mode, IFBIF_STICKY)
+}
+
+func (h *Handle) setBridgePortFlag(link Link, mode bool, flag uint32) error {
+	name, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	return bridgeSetPortFlag(fd, name, flag, mode)
 }
 
 // LinkSetNsFd puts the device into a new network namespace. The
@@ -525,68 +919,9 @@ func LinkAdd(link Link) error {
 func (h *Handle) LinkAdd(link Link) error {
 	switch l := link.(type) {
 	case *Veth:
-		/* ioctl用のソケット作成 */
-		fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
-		if err != nil {
-			return fmt.Errorf("socket failed: %v.\n", err)
-		}
-		defer unix.Close(fd)
-
-		/* epairの作成指示 */
-		var ifr Ifreq
-		copy(ifr.Name[:], "epair\x00")
-
-		_, _, errno := unix.Syscall(
-			unix.SYS_IOCTL,
-			uintptr(fd),
-			uintptr(unix.SIOCIFCREATE2),
-			uintptr(unsafe.Pointer(&ifr)),
-		)
-		if errno != 0 {
-			return fmt.Errorf("ioctl failed: %v.\n", errno)
-		}
-	
-		/* 作成したepair名を取得 */
-		oldA := ifr.Name[:]
-		nullIndex := len(oldA)
-		for i, b := range oldA {
-			if b == 0 {
-				nullIndex = i
-				break
-			}
-		}
-		oldB, err := atob(oldA[:nullIndex])
-		if err != nil {
-			return fmt.Errorf("atob error: %v.\n", err)
-		}
-
-		/* 作成したepairの構造体を取得 */
-		oldLinkA, err := LinkByName(string(oldA))
-		if err != nil {
-			return fmt.Errorf("LinkByName(A) error: %v.\n", err)
-		}
-		oldLinkB, err := LinkByName(string(oldB))
-		if err != nil {
-			return fmt.Errorf("LinkByName(B) error: %v.\n", err)
-		}
-
-		/* 作成したいepair名を取得 */
-		newA := []byte(l.Attrs().Name)
-		newA = append(newA, 0)
-		newB := []byte(l.PeerName)
-		newB = append(newB, 0)
-
-		/* リネーム */
-		if err = LinkSetName(oldLinkA, string(newA)); err != nil {
-			return fmt.Errorf("LinkSetName(A) error: %v.\n", err)
-		}
-		if err = LinkSetName(oldLinkB, string(newB)); err != nil {
-			return fmt.Errorf("LinkSetName(B) error: %v.\n", err)
-		}
+		return h.linkAddEpair(l)
 
-		return nil
-
-	case *Bridge: 
+	case *Bridge, *Wireguard:
 		/* ioctl用のソケット作成 */
 		fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
 		if err != nil {
@@ -594,9 +929,13 @@ func (h *Handle) LinkAdd(link Link) error {
 		}
 		defer unix.Close(fd)
 
-		/* bridgeの作成指示 */
+		/* bridge/wgの作成指示 */
+		cloner := "bridge\x00"
+		if _, ok := l.(*Wireguard); ok {
+			cloner = "wg\x00"
+		}
 		var ifr Ifreq
-		copy(ifr.Name[:], "bridge\x00")
+		copy(ifr.Name[:], cloner)
 
 		_, _, errno := unix.Syscall(
 			unix.SYS_IOCTL,
@@ -626,6 +965,22 @@ func (h *Handle) LinkAdd(link Link) error {
 			return fmt.Errorf("LinkSetName() error: %v.\n", err)
 		}
 
+		// do not leave a half configured link behind
+		if err := linkSetIoctlAttrs(fd, l.Attrs()); err != nil {
+			linkDestroy(fd, l.Attrs().Name)
+			return err
+		}
+
+		if bridge, ok := l.(*Bridge); ok {
+			if err := bridgeSetParams(fd, bridge); err != nil {
+				linkDestroy(fd, l.Attrs().Name)
+				return err
+			}
+		}
+
+		if base := l.Attrs(); base.MasterIndex != 0 {
+			return h.LinkSetMasterByIndex(link, base.MasterIndex)
+		}
 		return nil
 
 	default:
@@ -633,6 +988,78 @@ func (h *Handle) LinkAdd(link Link) error {
 	}
 }
 
+// setLinkAttrs sets the attributes of the link and fills in what FreeBSD
+// does not report over netlink.
+func setLinkAttrs(link Link, base LinkAttrs, linkSlave LinkSlave) {
+	*link.Attrs() = base
+	link.Attrs().Slave = linkSlave
+}
+
+// fillIoctlAttrs fills the attributes of the link that neither netlink
+// nor the routing socket report, using ioctls on fd. The ioctls address
+// the link by name in the current vnet.
+func fillIoctlAttrs(fd int, link Link) {
+	base := link.Attrs()
+	if groups, err := linkGroups(fd, base.Name); err == nil {
+		base.Groups = groups
+	}
+	if fib, err := linkFib(fd, base.Name); err == nil {
+		base.Fib = fib
+		if base.Slave == nil && fib != 0 {
+			// a link outside the default FIB is the FreeBSD analogue
+			// of a vrf slave
+			base.Slave = &VrfSlave{Table: uint32(fib)}
+		}
+	}
+	if data, err := linkIfDataByName(fd, base.Name); err == nil {
+		if base.Statistics == nil {
+			base.Statistics = data.statistics()
+		} else {
+			base.Statistics.TxMulticast = data.Omcasts
+		}
+	}
+	if flags, err := linkRawFlags(fd, base.Name); err == nil {
+		base.RawFlags = flags
+		base.Flags = linkFlags(flags)
+		base.Promisc, base.Allmulti, base.Multi = 0, 0, 0
+		if flags&(unix.IFF_PROMISC|unix.IFF_PPROMISC) != 0 {
+			base.Promisc = 1
+		}
+		if flags&unix.IFF_ALLMULTI != 0 {
+			base.Allmulti = 1
+		}
+		if flags&unix.IFF_MULTICAST != 0 {
+			base.Multi = 1
+		}
+	}
+
+	// if_bridge parameters are not reported over netlink
+	if bridge, ok := link.(*Bridge); ok {
+		parseBridgeParams(bridge)
+	}
+}
+
+// linkSetIoctlAttrs applies the attributes of a newly created link that
+// are set through ioctls rather than at creation.
+func linkSetIoctlAttrs(fd int, base *LinkAttrs) error {
+	if base.Alias != "" {
+		if err := linkSetDescription(fd, base.Name, base.Alias); err != nil {
+			return err
+		}
+	}
+	for _, group := range base.Groups {
+		if err := linkModifyGroup(fd, base.Name, group, unix.SIOCAIFGROUP); err != nil {
+			return err
+		}
+	}
+	if base.Fib != 0 {
+		if err := linkSetFib(fd, base.Name, base.Fib); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func atob(a []byte) ([]byte, error) {
 	if len(a) < 2 {
 		return nil, fmt.Errorf("invalid interface name: %q", a)
@@ -652,7 +1079,19 @@ func LinkModify(link Link) error {
 }
 
 func (h *Handle) LinkModify(link Link) error {
-	return h.linkModify(link, nlunix.NLM_F_REQUEST|nlunix.NLM_F_ACK)
+	if err := h.linkModify(link, nlunix.NLM_F_REQUEST|nlunix.NLM_F_ACK); err != nil {
+		return err
+	}
+	// if_bridge parameters are not set over netlink
+	if bridge, ok := link.(*Bridge); ok {
+		fd, err := getSocketUDP()
+		if err != nil {
+			return fmt.Errorf("socket error: %w", err)
+		}
+		defer unix.Close(fd)
+		return bridgeSetParams(fd, bridge)
+	}
+	return nil
 }
 
 func (h *Handle) linkModify(link Link, flags int) error {
@@ -1058,16 +1497,36 @@ func LinkDel(link Link) error {
 // LinkDel deletes link device. Either Index or Name must be set in
 // the link object for it to be deleted. The other values are ignored.
 // Equivalent to: `ip link del $link`
+//
+// Only cloned interfaces (epair, bridge, wg, ...) can be deleted. The
+// kernel destroys both ends of an epair at once; if the Veth end itself
+// is not found, e.g. because it was moved into a jail, the pair is
+// destroyed through PeerName instead.
 func (h *Handle) LinkDel(link Link) error {
-	fd, err := unix.Socket(unix.AF_INET, unix.SOCK_DGRAM, 0)
+	name, err := h.linkName(link)
 	if err != nil {
-		fmt.Printf("Error: %v.\n", err)
-		os.Exit(1)
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
 	}
 	defer unix.Close(fd)
 
+	err = linkDestroy(fd, name)
+	if veth, ok := link.(*Veth); ok && veth.PeerName != "" {
+		if _, notFound := err.(LinkNotFoundError); notFound {
+			err = linkDestroy(fd, veth.PeerName)
+		}
+	}
+	return err
+}
+
+// linkDestroy destroys the cloned interface name.
+func linkDestroy(fd int, name string) error {
 	var ifr Ifreq
-	copy(ifr.Name[:], link.Attrs().Name)
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
 
 	_, _, errno := unix.Syscall(
 		unix.SYS_IOCTL,
@@ -1075,16 +1534,19 @@ func (h *Handle) LinkDel(link Link) error {
 		uintptr(unix.SIOCIFDESTROY),
 		uintptr(unsafe.Pointer(&ifr)),
 	)
-	if errno != 0 {
-		fmt.Printf("ioctl error: %v.\n", errno)
-		os.Exit(1)
+	switch errno {
+	case 0:
+		return nil
+	case unix.ENXIO:
+		return LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	case unix.EINVAL:
+		return fmt.Errorf("Link %s is not a cloned interface: %w", name, errno)
 	}
-
-	return nil
+	return fmt.Errorf("ioctl SIOCIFDESTROY error: %w", errno)
 }
 
 func (h *Handle) linkByNameDump(name string) (Link, error) {
-	links, err := h.LinkList()
+	links, err := h.linkList()
 	if err != nil {
 		return nil, err
 	}
@@ -1111,6 +1573,17 @@ func LinkByName(name string) (Link, error) {
 
 // LinkByName finds a link by name and returns a pointer to the object.
 func (h *Handle) LinkByName(name string) (Link, error) {
+	link, err := h.linkByName(name)
+	if err != nil {
+		return nil, err
+	}
+	h.fillLinkAttrs(link)
+	return link, nil
+}
+
+// linkByName is LinkByName without the attributes filled in by
+// fillLinkAttrs.
+func (h *Handle) linkByName(name string) (Link, error) {
 	if h.lookupByDump {
 		return h.linkByNameDump(name)
 	}
@@ -1136,8 +1609,13 @@ func (h *Handle) LinkByName(name string) (Link, error) {
 		h.lookupByDump = true
 		return h.linkByNameDump(name)
 	}
-
-	return link, err
+	if netlinkUnsupported(err) && h.inCurrentVnet() {
+		return linkByNameRIB(name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
 }
 
 // LinkByIndex finds a link by index and returns a pointer to the object.
@@ -1147,6 +1625,17 @@ func LinkByIndex(index int) (Link, error) {
 
 // LinkByIndex finds a link by index and returns a pointer to the object.
 func (h *Handle) LinkByIndex(index int) (Link, error) {
+	link, err := h.linkByIndex(index)
+	if err != nil {
+		return nil, err
+	}
+	h.fillLinkAttrs(link)
+	return link, nil
+}
+
+// linkByIndex is LinkByIndex without the attributes filled in by
+// fillLinkAttrs.
+func (h *Handle) linkByIndex(index int) (Link, error) {
 	req := h.newNetlinkRequest(nlunix.RTM_GETLINK, nlunix.NLM_F_ACK)
 
 	msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
@@ -1155,7 +1644,33 @@ func (h *Handle) LinkByIndex(index int) (Link, error) {
 	attr := nl.NewRtAttr(nlunix.IFLA_EXT_MASK, nl.Uint32Attr(nl.RTEXT_FILTER_VF))
 	req.AddData(attr)
 
-	return execGetLink(req)
+	link, err := execGetLink(req)
+	if netlinkUnsupported(err) && h.inCurrentVnet() {
+		return linkByIndexRIB(index)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return link, nil
+}
+
+// fillLinkAttrs sets the attributes of the given links that FreeBSD does
+// not report over netlink or the routing socket, such as the groups, the
+// FIB and the bridge membership. This costs ioctls on every bridge, so
+// lists fill all their links at once. The ioctls act on the current vnet,
+// the links of a handle in another one are left as reported.
+func (h *Handle) fillLinkAttrs(links ...Link) {
+	if !h.inCurrentVnet() {
+		return
+	}
+	if fd, err := getSocketUDP(); err == nil {
+		for _, link := range links {
+			fillIoctlAttrs(fd, link)
+		}
+		unix.Close(fd)
+	}
+	fillBridgePortAttrs(links...)
+	fillVethPeers(links...)
 }
 
 func execGetLink(req *nl.NetlinkRequest) (Link, error) {
@@ -1181,6 +1696,73 @@ func execGetLink(req *nl.NetlinkRequest) (Link, error) {
 	}
 }
 
+// linkOfKind returns an empty link of the type for the IFLA_INFO_KIND of
+// a link.
+func linkOfKind(linkType string) Link {
+	switch linkType {
+	case "dummy":
+		return &Dummy{}
+	case "ifb":
+		return &Ifb{}
+	case "bridge":
+		return &Bridge{}
+	case "vlan":
+		return &Vlan{}
+	case "netkit":
+		return &Netkit{}
+	case "veth", "epair":
+		return &Veth{}
+	case "wireguard", "wg":
+		return &Wireguard{}
+	case "vxlan":
+		return &Vxlan{}
+	case "bond":
+		return &Bond{}
+	case "ipvlan":
+		return &IPVlan{}
+	case "ipvtap":
+		return &IPVtap{}
+	case "macvlan":
+		return &Macvlan{}
+	case "macvtap":
+		return &Macvtap{}
+	case "geneve":
+		return &Geneve{}
+	case "gretap":
+		return &Gretap{}
+	case "ip6gretap":
+		return &Gretap{}
+	case "ipip":
+		return &Iptun{}
+	case "ip6tnl":
+		return &Ip6tnl{}
+	case "sit":
+		return &Sittun{}
+	case "gre":
+		return &Gretun{}
+	case "ip6gre":
+		return &Gretun{}
+	case "vti", "vti6":
+		return &Vti{}
+	case "vrf":
+		return &Vrf{}
+	case "gtp":
+		return &GTP{}
+	case "xfrm":
+		return &Xfrmi{}
+	case "tun":
+		return &Tuntap{}
+	case "ipoib":
+		return &IPoIB{}
+	case "can":
+		return &Can{}
+	case "bareudp":
+		return &BareUDP{}
+	default:
+		return &GenericLink{LinkType: linkType}
+	}
+}
+
 // LinkDeserialize deserializes a raw message received from netlink into
 // a link object.
 func LinkDeserialize(hdr *nlunix.NlMsghdr, m []byte) (Link, error) {
@@ -1223,68 +1805,7 @@ func LinkDeserialize(hdr *nlunix.NlMsghdr, m []byte) (Link, error) {
 				switch info.Attr.Type {
 				case nl.IFLA_INFO_KIND:
 					linkType = string(info.Value[:len(info.Value)-1])
-					switch linkType {
-					case "dummy":
-						link = &Dummy{}
-					case "ifb":
-						link = &Ifb{}
-					case "bridge":
-						link = &Bridge{}
-					case "vlan":
-						link = &Vlan{}
-					case "netkit":
-						link = &Netkit{}
-					case "veth":
-						link = &Veth{}
-					case "wireguard":
-						link = &Wireguard{}
-					case "vxlan":
-						link = &Vxlan{}
-					case "bond":
-						link = &Bond{}
-					case "ipvlan":
-						link = &IPVlan{}
-					case "ipvtap":
-						link = &IPVtap{}
-					case "macvlan":
-						link = &Macvlan{}
-					case "macvtap":
-						link = &Macvtap{}
-					case "geneve":
-						link = &Geneve{}
-					case "gretap":
-						link = &Gretap{}
-					case "ip6gretap":
-						link = &Gretap{}
-					case "ipip":
-						link = &Iptun{}
-					case "ip6tnl":
-						link = &Ip6tnl{}
-					case "sit":
-						link = &Sittun{}
-					case "gre":
-						link = &Gretun{}
-					case "ip6gre":
-						link = &Gretun{}
-					case "vti", "vti6":
-						link = &Vti{}
-					case "vrf":
-						link = &Vrf{}
-					case "gtp":
-						link = &GTP{}
-					case "xfrm":
-						link = &Xfrmi{}
-					case "tun":
-						link = &Tuntap{}
-					case "ipoib":
-						link = &IPoIB{}
-					case "can":
-						link = &Can{}
-					case "bareudp":
-						link = &BareUDP{}
-					default:
-						link = &GenericLink{LinkType: linkType}
-					}
+					link = linkOfKind(linkType)
 				case nl.IFLA_INFO_DATA:
 					data, err := nl.ParseRouteAttr(info.Value)
 					if err != nil {
@@ -1399,8 +1920,11 @@ func LinkDeserialize(hdr *nlunix.NlMsghdr, m []byte) (Link, error) {
 				return nil, err
 			}
 		case nlunix.IFLA_STATS64:
+			// older kernels send fewer counters, leave the rest zero
 			stats64 = new(LinkStatistics64)
-			if err := binary.Read(bytes.NewBuffer(attr.Value[:]), nl.NativeEndian(), stats64); err != nil {
+			buf := make([]byte, binary.Size(stats64))
+			copy(buf, attr.Value)
+			if err := binary.Read(bytes.NewBuffer(buf), nl.NativeEndian(), stats64); err != nil {
 				return nil, err
 			}
 		case nlunix.IFLA_XDP:
@@ -1487,8 +2011,7 @@ func LinkDeserialize(hdr *nlunix.NlMsghdr, m []byte) (Link, error) {
 	if link == nil {
 		link = &Device{}
 	}
-	*link.Attrs() = base
-	link.Attrs().Slave = linkSlave
+	setLinkAttrs(link, base, linkSlave)
 
 	// If the tuntap attributes are not updated by netlink due to
 	// an older driver, use sysfs
@@ -1550,6 +2073,17 @@ func LinkList() ([]Link, error) {
 // LinkList gets a list of link devices.
 // Equivalent to: `ip link show`
 func (h *Handle) LinkList() ([]Link, error) {
+	links, err := h.linkList()
+	if err != nil {
+		return nil, err
+	}
+	h.fillLinkAttrs(links...)
+	return links, nil
+}
+
+// linkList is LinkList without the attributes filled in by
+// fillLinkAttrs.
+func (h *Handle) linkList() ([]Link, error) {
 	// NOTE(vish): This duplicates functionality in net/iface_linux.go, but we need
 	//             to get the message ourselves to parse link type.
 	req := h.newNetlinkRequest(nlunix.RTM_GETLINK, nlunix.NLM_F_DUMP)
@@ -1560,6 +2094,9 @@ func (h *Handle) LinkList() ([]Link, error) {
 	req.AddData(attr)
 
 	msgs, err := req.Execute(nlunix.NETLINK_ROUTE, nlunix.RTM_NEWLINK)
+	if netlinkUnsupported(err) && h.inCurrentVnet() {
+		return linkListRIB()
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -1572,7 +2109,6 @@ func (h *Handle) LinkList() ([]Link, error) {
 		}
 		res = append(res, link)
 	}
-
 	return res, nil
 }
 
@@ -1586,13 +2122,13 @@ type LinkUpdate struct {
 // LinkSubscribe takes a chan down which notifications will be sent
 // when links change.  Close the 'done' chan to stop subscription.
 func LinkSubscribe(ch chan<- LinkUpdate, done <-chan struct{}) error {
-	return linkSubscribeAt(vnet.None(), vnet.None(), ch, done, nil, false, 0, nil, false)
+	return linkSubscribeAt(vnet.None(), vnet.None(), ch, done, nil, false, false, 0, nil, false)
 }
 
 // LinkSubscribeAt works like LinkSubscribe plus it allows the caller
 // to choose the network namespace in which to subscribe (ns).
 func LinkSubscribeAt(ns vnet.VjHandle, ch chan<- LinkUpdate, done <-chan struct{}) error {
-	return linkSubscribeAt(ns, vnet.None(), ch, done, nil, false, 0, nil, false)
+	return linkSubscribeAt(ns, vnet.None(), ch, done, nil, false, false, 0, nil, false)
 }
 
 // LinkSubscribeOptions contains a set of options to use with
@@ -1614,13 +2150,19 @@ func LinkSubscribeWithOptions(ch chan<- LinkUpdate, done <-chan struct{}, option
 		none := vnet.None()
 		options.Namespace = &none
 	}
-	return linkSubscribeAt(*options.Namespace, vnet.None(), ch, done, options.ErrorCallback, options.ListExisting,
+	return linkSubscribeAt(*options.Namespace, vnet.None(), ch, done, options.ErrorCallback, options.ListExisting, false,
 		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize)
 }
 
-func linkSubscribeAt(newNs, curNs vnet.VjHandle, ch chan<- LinkUpdate, done <-chan struct{}, cberr func(error), listExisting bool,
+// linkSubscribeAt subscribes to link updates in newNs. With markListed, an
+// update with Header.Type NLMSG_DONE and no Link follows the existing
+// links.
+func linkSubscribeAt(newNs, curNs vnet.VjHandle, ch chan<- LinkUpdate, done <-chan struct{}, cberr func(error), listExisting, markListed bool,
 	rcvbuf int, rcvTimeout *unix.Timeval, rcvbufForce bool) error {
 	s, err := nl.SubscribeAt(newNs, curNs, nlunix.NETLINK_ROUTE, nlunix.RTNLGRP_LINK)
+	if netlinkUnsupported(err) && !newNs.IsOpen() {
+		return linkSubscribeRouteSocket(ch, done, cberr, listExisting, markListed, rcvbuf, rcvTimeout)
+	}
 	if err != nil {
 		return err
 	}
@@ -1669,6 +2211,10 @@ func linkSubscribeAt(newNs, curNs vnet.VjHandle, ch chan<- LinkUpdate, done <-ch
 			}
 			for _, m := range msgs {
 				if m.Header.Type == nlunix.NLMSG_DONE {
+					if markListed {
+						ch <- LinkUpdate{Header: nlunix.NlMsghdr(m.Header)}
+						markListed = false
+					}
 					continue
 				}
 				if m.Header.Type == nlunix.NLMSG_ERROR {
@@ -1691,7 +2237,11 @@ func linkSubscribeAt(newNs, curNs vnet.VjHandle, ch chan<- LinkUpdate, done <-ch
 					}
 					continue
 				}
-				ch <- LinkUpdate{IfInfomsg: *ifmsg, Header: header, Link: link}
+				update := LinkUpdate{IfInfomsg: *ifmsg, Header: header, Link: link}
+				if !newNs.IsOpen() {
+					fillUpdateGroups(&update)
+				}
+				ch <- update
 			}
 		}
 	}()
@@ -2066,7 +2616,8 @@ func parseMacvlanData(link Link, data []nlsyscall.NetlinkRouteAttr) {
 	}
 }
 
-// copied from pkg/net_linux.go
+// linkFlags translates the FreeBSD IFF_* flag word to net.Flags, see
+// pkg/net/interface_bsd.go.
 func linkFlags(rawFlags uint32) net.Flags {
 	var f net.Flags
 	if rawFlags&unix.IFF_UP != 0 {
@@ -2084,6 +2635,9 @@ func linkFlags(rawFlags uint32) net.Flags {
 	if rawFlags&unix.IFF_MULTICAST != 0 {
 		f |= net.FlagMulticast
 	}
+	if rawFlags&unix.IFF_DRV_RUNNING != 0 {
+		f |= net.FlagRunning
+	}
 	return f
 }
 
@@ -2781,66 +3335,6 @@ func parseXfrmiData(link Link, data []nlsyscall.NetlinkRouteAttr) {
 	}
 }
 
-func vethStatsSerialize(stats ethtoolStats) ([]byte, error) {
-	statsSize := int(unsafe.Sizeof(stats)) + int(stats.nStats)*int(unsafe.Sizeof(uint64(0)))
-	b := make([]byte, 0, statsSize)
-	buf := bytes.NewBuffer(b)
-	err := binary.Write(buf, nl.NativeEndian(), stats)
-	return buf.Bytes()[:statsSize], err
-}
-
-type vethEthtoolStats struct {
-	Cmd    uint32
-	NStats uint32
-	Peer   uint64
-	// Newer kernels have XDP stats in here, but we only care
-	// to extract the peer ifindex here.
-}
-
-func vethStatsDeserialize(b []byte) (vethEthtoolStats, error) {
-	var stats = vethEthtoolStats{}
-	err := binary.Read(bytes.NewReader(b), nl.NativeEndian(), &stats)
-	return stats, err
-}
-
-// VethPeerIndex get veth peer index.
-func VethPeerIndex(link *Veth) (int, error) {
-	fd, err := getSocketUDP()
-	if err != nil {
-		return -1, err
-	}
-	defer syscall.Close(fd)
-
-	ifreq, sSet := newIocltStringSetReq(link.Name)
-	_, _, errno := syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), SIOCETHTOOL, uintptr(unsafe.Pointer(ifreq)))
-	if errno != 0 {
-		return -1, fmt.Errorf("SIOCETHTOOL request for %q failed, errno=%v", link.Attrs().Name, errno)
-	}
-
-	stats := ethtoolStats{
-		cmd:    ETHTOOL_GSTATS,
-		nStats: sSet.data[0],
-	}
-
-	buffer, err := vethStatsSerialize(stats)
-	if err != nil {
-		return -1, err
-	}
-
-	ifreq.Data = uintptr(unsafe.Pointer(&buffer[0]))
-	_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, uintptr(fd), SIOCETHTOOL, uintptr(unsafe.Pointer(ifreq)))
-	if errno != 0 {
-		return -1, fmt.Errorf("SIOCETHTOOL request for %q failed, errno=%v", link.Attrs().Name, errno)
-	}
-
-	vstats, err := vethStatsDeserialize(buffer)
-	if err != nil {
-		return -1, err
-	}
-
-	return int(vstats.Peer), nil
-}
-
 func parseTuntapData(link Link, data []nlsyscall.NetlinkRouteAttr) {
 	tuntap := link.(*Tuntap)
 	for _, datum := range data {
@@ -0,0 +1,246 @@
+package netlink
+
+import (
+	"fmt"
+	"unsafe"
+
+	"github.com/oss-fun/netlink/nl"
+	"github.com/oss-fun/netlink/nlunix"
+	"golang.org/x/sys/unix"
+)
+
+// IFG_ALL is the group every interface is a member of. It is not reported
+// in LinkAttrs.Groups.
+const IFG_ALL = "all"
+
+// LinkAddGroup adds the link to the interface group, creating the group
+// if needed. Group names may not end in a digit. The kernel sends no link
+// message for group changes, link subscriptions report the new groups
+// with the next message about the link.
+// Equivalent to: `ifconfig $link group $group`
+func LinkAddGroup(link Link, group string) error {
+	return pkgHandle.LinkAddGroup(link, group)
+}
+
+// LinkAddGroup adds the link to the interface group, creating the group
+// if needed. Group names may not end in a digit. The kernel sends no link
+// message for group changes, link subscriptions report the new groups
+// with the next message about the link.
+// Equivalent to: `ifconfig $link group $group`
+func (h *Handle) LinkAddGroup(link Link, group string) error {
+	return h.linkModifyGroup(link, group, unix.SIOCAIFGROUP)
+}
+
+// LinkDelGroup removes the link from the interface group. Like
+// LinkAddGroup, it sends no link message.
+// Equivalent to: `ifconfig $link -group $group`
+func LinkDelGroup(link Link, group string) error {
+	return pkgHandle.LinkDelGroup(link, group)
+}
+
+// LinkDelGroup removes the link from the interface group. Like
+// LinkAddGroup, it sends no link message.
+// Equivalent to: `ifconfig $link -group $group`
+func (h *Handle) LinkDelGroup(link Link, group string) error {
+	return h.linkModifyGroup(link, group, unix.SIOCDIFGROUP)
+}
+
+func (h *Handle) linkModifyGroup(link Link, group string, req uintptr) error {
+	if group == "" || len(group) >= unix.IFNAMSIZ {
+		return fmt.Errorf("invalid group name %q", group)
+	}
+	name, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	return linkModifyGroup(fd, name, group, req)
+}
+
+func linkModifyGroup(fd int, name, group string, req uintptr) error {
+	var ifgr ifGroupreqGroup
+	copy(ifgr.Name[:unix.IFNAMSIZ-1], name)
+	copy(ifgr.Group[:unix.IFNAMSIZ-1], group)
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		req,
+		uintptr(unsafe.Pointer(&ifgr)),
+	)
+	if errno == unix.ENXIO {
+		return LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	if errno != 0 {
+		op := "SIOCAIFGROUP"
+		if req == unix.SIOCDIFGROUP {
+			op = "SIOCDIFGROUP"
+		}
+		return fmt.Errorf("ioctl %s error: %w", op, errno)
+	}
+	return nil
+}
+
+// LinkGroups returns the interface groups of the link, except IFG_ALL.
+// Equivalent to: `ifconfig -g $link`
+func LinkGroups(link Link) ([]string, error) {
+	return pkgHandle.LinkGroups(link)
+}
+
+// LinkGroups returns the interface groups of the link, except IFG_ALL.
+// Equivalent to: `ifconfig -g $link`
+func (h *Handle) LinkGroups(link Link) ([]string, error) {
+	name, err := h.linkName(link)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return nil, fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	return linkGroups(fd, name)
+}
+
+// LinkListByGroup gets the links that are members of the interface group.
+// Equivalent to: `ifconfig -g $group`
+func LinkListByGroup(group string) ([]Link, error) {
+	return pkgHandle.LinkListByGroup(group)
+}
+
+// LinkListByGroup gets the links that are members of the interface group.
+// Equivalent to: `ifconfig -g $group`
+func (h *Handle) LinkListByGroup(group string) ([]Link, error) {
+	fd, err := getSocketUDP()
+	if err != nil {
+		return nil, fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	members, err := groupMembers(fd, group)
+	if err != nil {
+		return nil, err
+	}
+	if len(members) == 0 {
+		return nil, nil
+	}
+	isMember := make(map[string]bool, len(members))
+	for _, m := range members {
+		isMember[m] = true
+	}
+
+	links, err := h.LinkList()
+	if err != nil {
+		return nil, err
+	}
+	var res []Link
+	for _, link := range links {
+		if isMember[link.Attrs().Name] {
+			res = append(res, link)
+		}
+	}
+	return res, nil
+}
+
+// linkGroups returns the groups of the link name using SIOCGIFGROUP.
+func linkGroups(fd int, name string) ([]string, error) {
+	groups, err := ifGroupList(fd, unix.SIOCGIFGROUP, name)
+	if err == unix.ENXIO {
+		return nil, LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ioctl SIOCGIFGROUP error: %w", err)
+	}
+	res := groups[:0]
+	for _, g := range groups {
+		if g != IFG_ALL {
+			res = append(res, g)
+		}
+	}
+	return res, nil
+}
+
+// groupMembers returns the names of the interfaces in group. A group
+// that does not exist has no members.
+func groupMembers(fd int, group string) ([]string, error) {
+	names, err := ifGroupList(fd, unix.SIOCGIFGMEMB, group)
+	if err == unix.ENOENT {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("ioctl SIOCGIFGMEMB error: %w", err)
+	}
+	return names, nil
+}
+
+// ifGroupList returns the names of the ifg_req list filled by
+// SIOCGIFGROUP or SIOCGIFGMEMB for name.
+func ifGroupList(fd int, req uintptr, name string) ([]string, error) {
+	size := uint32(unsafe.Sizeof(ifgReq{}))
+	for {
+		// A call without buffer reports the size needed, EINVAL means
+		// entries were added in between.
+		var ifgr ifGroupreq
+		copy(ifgr.Name[:unix.IFNAMSIZ-1], name)
+		if errno := ifGroupIoctl(fd, req, &ifgr); errno != 0 {
+			return nil, errno
+		}
+		if ifgr.Len == 0 {
+			return nil, nil
+		}
+		reqs := make([]ifgReq, ifgr.Len/size)
+		ifgr.Groups = unsafe.Pointer(&reqs[0])
+		errno := ifGroupIoctl(fd, req, &ifgr)
+		if errno == unix.EINVAL {
+			continue
+		}
+		if errno != 0 {
+			return nil, errno
+		}
+		// The kernel leaves ifgr_len as passed, entries removed in
+		// between leave the tail of the buffer zeroed.
+		names := make([]string, 0, len(reqs))
+		for _, r := range reqs {
+			if r.Name[0] == 0 {
+				break
+			}
+			names = append(names, nl.BytesToString(r.Name[:]))
+		}
+		return names, nil
+	}
+}
+
+func ifGroupIoctl(fd int, req uintptr, ifgr *ifGroupreq) unix.Errno {
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		req,
+		uintptr(unsafe.Pointer(ifgr)),
+	)
+	return errno
+}
+
+// fillUpdateGroups sets the groups of the link of an RTM_NEWLINK update,
+// which neither netlink nor the routing socket report. The kernel sends
+// no link message when the groups change, so a change shows in the next
+// message about the link. SIOCGIFGROUP acts on the current vnet only.
+func fillUpdateGroups(update *LinkUpdate) {
+	if update.Header.Type != nlunix.RTM_NEWLINK || update.Link == nil {
+		return
+	}
+	fd, err := getSocketUDP()
+	if err != nil {
+		return
+	}
+	defer unix.Close(fd)
+	if groups, err := linkGroups(fd, update.Attrs().Name); err == nil {
+		update.Attrs().Groups = groups
+	}
+}
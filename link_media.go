@@ -0,0 +1,269 @@
+package netlink
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Media is a media word of FreeBSD net/if_media.h. It combines the
+// network type, the subtype and the options of the media.
+type Media uint32
+
+// Media word fields and generic values.
+const (
+	IFM_NMASK      Media = 0x000000e0 // network type
+	IFM_TMASK      Media = 0x0000001f // subtype
+	IFM_ETH_XTYPE  Media = 0x00007800 // extended ethernet subtype
+	IFM_ETH_XSHIFT       = 6
+	IFM_OMASK      Media = 0x0000ff00 // type specific options
+	IFM_GMASK      Media = 0x0ff00000 // global options
+
+	IFM_ETHER     Media = 0x00000020
+	IFM_IEEE80211 Media = 0x00000080
+
+	IFM_AUTO   = 0 // autoselect
+	IFM_MANUAL = 1
+	IFM_NONE   = 2
+
+	IFM_FDX   Media = 0x00100000 // full duplex
+	IFM_HDX   Media = 0x00200000 // half duplex
+	IFM_FLOW  Media = 0x00400000 // hardware flow control
+	IFM_FLAG0 Media = 0x01000000
+	IFM_FLAG1 Media = 0x02000000
+	IFM_FLAG2 Media = 0x04000000
+	IFM_LOOP  Media = 0x08000000 // hardware loopback
+
+	IFM_ETH_MASTER  Media = 0x00000100 // master mode of 1000baseT
+	IFM_ETH_RXPAUSE Media = 0x00000200 // receive PAUSE frames
+	IFM_ETH_TXPAUSE Media = 0x00000400 // transmit PAUSE frames
+)
+
+// Media status bits of ifm_status.
+const (
+	IFM_AVALID = 0x00000001 // active bit valid
+	IFM_ACTIVE = 0x00000002 // interface attached to working net
+)
+
+// mediaSubtype is an ethernet subtype with its ifconfig(8) name and
+// baudrate in Mb/s.
+type mediaSubtype struct {
+	name  string
+	speed int
+}
+
+// ethernetSubtypes are the ethernet subtypes indexed by their extended
+// subtype number.
+var ethernetSubtypes = map[uint32]mediaSubtype{
+	3:  {"10baseT/UTP", 10},
+	4:  {"10base2/BNC", 10},
+	5:  {"10base5/AUI", 10},
+	6:  {"100baseTX", 100},
+	7:  {"100baseFX", 100},
+	8:  {"100baseT4", 100},
+	9:  {"100baseVG", 100},
+	10: {"100baseT2", 100},
+	11: {"1000baseSX", 1000},
+	12: {"10baseSTP", 10},
+	13: {"10baseFL", 10},
+	14: {"1000baseLX", 1000},
+	15: {"1000baseCX", 1000},
+	16: {"1000baseT", 1000},
+	17: {"homePNA", 1},
+	18: {"10Gbase-LR", 10000},
+	19: {"10Gbase-SR", 10000},
+	20: {"10Gbase-CX4", 10000},
+	21: {"2500Base-SX", 2500},
+	22: {"10Gbase-Twinax", 10000},
+	23: {"10Gbase-Twinax-Long", 10000},
+	24: {"10Gbase-LRM", 10000},
+	25: {"Unknown", 0},
+	26: {"10Gbase-T", 10000},
+	27: {"40Gbase-CR4", 40000},
+	28: {"40Gbase-SR4", 40000},
+	29: {"40Gbase-LR4", 40000},
+	30: {"1000Base-KX", 1000},
+	31: {"Other", 0},
+	32: {"10GBase-KX4", 10000},
+	33: {"10GBase-KR", 10000},
+	34: {"10GBase-CR1", 10000},
+	35: {"20GBase-KR2", 20000},
+	36: {"2500Base-KX", 2500},
+	37: {"2500Base-T", 2500},
+	38: {"5000Base-T", 5000},
+	39: {"50GBase-PCIE", 50000},
+	40: {"25GBase-PCIE", 25000},
+	41: {"1000Base-SGMII", 1000},
+	42: {"10GBase-SFI", 10000},
+	43: {"40GBase-XLPPI", 40000},
+	44: {"1000Base-CX-SGMII", 1000},
+	45: {"40GBase-KR4", 40000},
+	46: {"10GBase-ER", 10000},
+	47: {"100GBase-CR4", 100000},
+	48: {"100GBase-SR4", 100000},
+	49: {"100GBase-KR4", 100000},
+	50: {"100GBase-LR4", 100000},
+	51: {"56GBase-R4", 56000},
+	52: {"100BaseT", 100},
+	53: {"25GBase-CR", 25000},
+	54: {"25GBase-KR", 25000},
+	55: {"25GBase-SR", 25000},
+	56: {"50GBase-CR2", 50000},
+	57: {"50GBase-KR2", 50000},
+	58: {"25GBase-LR", 25000},
+	59: {"10GBase-AOC", 10000},
+	60: {"25GBase-ACC", 25000},
+	61: {"25GBase-AOC", 25000},
+	62: {"100M-SGMII", 100},
+	63: {"2500Base-X", 2500},
+}
+
+// mediaGenericNames are the subtypes shared by all network types.
+var mediaGenericNames = map[uint32]string{
+	IFM_AUTO:   "autoselect",
+	IFM_MANUAL: "manual",
+	IFM_NONE:   "none",
+}
+
+// mediaOptions are the option names of ifconfig(8) mediaopt.
+var mediaOptions = []struct {
+	opt  Media
+	name string
+}{
+	{IFM_FDX, "full-duplex"},
+	{IFM_HDX, "half-duplex"},
+	{IFM_FLOW, "flowcontrol"},
+	{IFM_FLAG0, "flag0"},
+	{IFM_FLAG1, "flag1"},
+	{IFM_FLAG2, "flag2"},
+	{IFM_LOOP, "hw-loopback"},
+	{IFM_ETH_MASTER, "master"},
+	{IFM_ETH_RXPAUSE, "rxpause"},
+	{IFM_ETH_TXPAUSE, "txpause"},
+}
+
+// Type returns the network type of the media, e.g. IFM_ETHER.
+func (m Media) Type() Media {
+	return m & IFM_NMASK
+}
+
+// Subtype returns the subtype number of the media including the
+// extended ethernet subtypes.
+func (m Media) Subtype() uint32 {
+	sub := uint32(m & IFM_TMASK)
+	if m.Type() == IFM_ETHER {
+		sub |= uint32(m&IFM_ETH_XTYPE) >> IFM_ETH_XSHIFT
+	}
+	return sub
+}
+
+// Options returns the names of the options set in the media.
+func (m Media) Options() []string {
+	var opts []string
+	for _, o := range mediaOptions {
+		if o.opt&IFM_OMASK != 0 && m.Type() != IFM_ETHER {
+			continue
+		}
+		if m&o.opt != 0 {
+			opts = append(opts, o.name)
+		}
+	}
+	return opts
+}
+
+// Speed returns the baudrate of the media in Mb/s, 0 if unknown.
+func (m Media) Speed() int {
+	if m.Type() != IFM_ETHER {
+		return 0
+	}
+	return ethernetSubtypes[m.Subtype()].speed
+}
+
+// Name returns the ifconfig(8) name of the media subtype.
+func (m Media) Name() string {
+	sub := m.Subtype()
+	if name, ok := mediaGenericNames[sub]; ok {
+		return name
+	}
+	if m.Type() == IFM_ETHER {
+		if st, ok := ethernetSubtypes[sub]; ok {
+			return st.name
+		}
+	}
+	return fmt.Sprintf("unknown-%d", sub)
+}
+
+// String returns the media like ifconfig(8) does, the subtype followed by
+// the options, e.g. "1000baseT <full-duplex>".
+func (m Media) String() string {
+	if opts := m.Options(); len(opts) > 0 {
+		return fmt.Sprintf("%s <%s>", m.Name(), strings.Join(opts, ","))
+	}
+	return m.Name()
+}
+
+// ParseMedia returns the media word of the network type for the subtype
+// and option names of ifconfig(8). Names are case insensitive.
+func ParseMedia(typ Media, subtype string, opts []string) (Media, error) {
+	m, ok := Media(0), false
+	for sub, name := range mediaGenericNames {
+		if strings.EqualFold(name, subtype) {
+			m, ok = typ|Media(sub), true
+		}
+	}
+	if !ok && typ == IFM_ETHER {
+		for sub, st := range ethernetSubtypes {
+			if strings.EqualFold(st.name, subtype) || strings.EqualFold(strings.SplitN(st.name, "/", 2)[0], subtype) {
+				m, ok = typ|Media(sub)&IFM_TMASK|Media(sub<<IFM_ETH_XSHIFT)&IFM_ETH_XTYPE, true
+			}
+		}
+	}
+	if !ok {
+		return 0, fmt.Errorf("unknown media %q", subtype)
+	}
+
+	for _, opt := range opts {
+		found := false
+		for _, o := range mediaOptions {
+			if strings.EqualFold(o.name, opt) && (o.opt&IFM_OMASK == 0 || typ == IFM_ETHER) {
+				m |= o.opt
+				found = true
+			}
+		}
+		if !found {
+			return 0, fmt.Errorf("unknown media option %q", opt)
+		}
+	}
+	return m, nil
+}
+
+// MediaDuplex is the duplex of a media.
+type MediaDuplex uint8
+
+const (
+	DUPLEX_UNKNOWN MediaDuplex = iota
+	DUPLEX_HALF
+	DUPLEX_FULL
+)
+
+func (d MediaDuplex) String() string {
+	switch d {
+	case DUPLEX_HALF:
+		return "half"
+	case DUPLEX_FULL:
+		return "full"
+	default:
+		return "unknown"
+	}
+}
+
+// LinkMedia is the media configuration and state of a link.
+type LinkMedia struct {
+	Current   Media   // configured media, may be autoselect
+	Active    Media   // media in use
+	Supported []Media // media the link can be set to
+	Status    int     // IFM_AVALID and IFM_ACTIVE
+	Speed     int     // Mb/s of the active media, 0 if unknown
+	Duplex    MediaDuplex
+	RxPause   bool // flow control on receive
+	TxPause   bool // flow control on transmit
+}
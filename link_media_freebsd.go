@@ -0,0 +1,163 @@
+package netlink
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+// LinkGetMedia returns the configured and active media of the link, the
+// media it supports and the speed, duplex and flow control in use.
+// Equivalent to: `ifconfig -m $link`
+func LinkGetMedia(link Link) (*LinkMedia, error) {
+	return pkgHandle.LinkGetMedia(link)
+}
+
+// LinkGetMedia returns the configured and active media of the link, the
+// media it supports and the speed, duplex and flow control in use.
+// Equivalent to: `ifconfig -m $link`
+func (h *Handle) LinkGetMedia(link Link) (*LinkMedia, error) {
+	name, err := h.linkName(link)
+	if err != nil {
+		return nil, err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return nil, fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	return linkMedia(fd, name)
+}
+
+// LinkSetMedia sets the media of the link by its ifconfig(8) subtype name
+// such as "1000baseT" or "autoselect", with options such as
+// "full-duplex". The network type is kept.
+// Equivalent to: `ifconfig $link media $media mediaopt $opts`
+func LinkSetMedia(link Link, media string, opts []string) error {
+	return pkgHandle.LinkSetMedia(link, media, opts)
+}
+
+// LinkSetMedia sets the media of the link by its ifconfig(8) subtype name
+// such as "1000baseT" or "autoselect", with options such as
+// "full-duplex". The network type is kept.
+// Equivalent to: `ifconfig $link media $media mediaopt $opts`
+func (h *Handle) LinkSetMedia(link Link, media string, opts []string) error {
+	name, err := h.linkName(link)
+	if err != nil {
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	cur, err := linkMedia(fd, name)
+	if err != nil {
+		return err
+	}
+	m, err := ParseMedia(cur.Current.Type(), media, opts)
+	if err != nil {
+		return err
+	}
+
+	ifr := ifreqMedia{Media: int32(m)}
+	copy(ifr.Name[:unix.IFNAMSIZ-1], name)
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(unix.SIOCSIFMEDIA),
+		uintptr(unsafe.Pointer(&ifr)),
+	)
+	if errno == unix.ENXIO {
+		return fmt.Errorf("media %s not supported by link %s", m, name)
+	}
+	if errno != 0 {
+		return fmt.Errorf("ioctl SIOCSIFMEDIA error: %w", errno)
+	}
+	return nil
+}
+
+// linkMedia returns the media of the link name using SIOCGIFXMEDIA, or
+// SIOCGIFMEDIA on kernels without extended media types.
+func linkMedia(fd int, name string) (*LinkMedia, error) {
+	req, op := uintptr(unix.SIOCGIFXMEDIA), "SIOCGIFXMEDIA"
+	var ifmr ifMediareq
+	copy(ifmr.Name[:unix.IFNAMSIZ-1], name)
+	errno := ifMediaIoctl(fd, req, &ifmr)
+	if errno == unix.ENOTTY {
+		req, op = unix.SIOCGIFMEDIA, "SIOCGIFMEDIA"
+		errno = ifMediaIoctl(fd, req, &ifmr)
+	}
+	if errno == unix.ENXIO {
+		return nil, LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	if errno != 0 {
+		return nil, fmt.Errorf("ioctl %s error: %w", op, errno)
+	}
+
+	m := linkMediaFromReq(&ifmr)
+	if ifmr.Count == 0 {
+		return m, nil
+	}
+	list := make([]int32, ifmr.Count)
+	ifmr.Ulist = unsafe.Pointer(&list[0])
+	if errno := ifMediaIoctl(fd, req, &ifmr); errno != 0 {
+		return nil, fmt.Errorf("ioctl %s error: %w", op, errno)
+	}
+	// media added in between do not fit and are left out
+	if int(ifmr.Count) < len(list) {
+		list = list[:ifmr.Count]
+	}
+	for _, sup := range list {
+		m.Supported = append(m.Supported, Media(uint32(sup)))
+	}
+	return m, nil
+}
+
+func linkMediaFromReq(ifmr *ifMediareq) *LinkMedia {
+	active := Media(uint32(ifmr.Active))
+	m := &LinkMedia{
+		Current: Media(uint32(ifmr.Current)),
+		Active:  active,
+		Status:  int(ifmr.Status),
+		Speed:   active.Speed(),
+	}
+	switch {
+	case active&IFM_FDX != 0:
+		m.Duplex = DUPLEX_FULL
+	case active&IFM_HDX != 0:
+		m.Duplex = DUPLEX_HALF
+	}
+	if active.Type() == IFM_ETHER {
+		m.RxPause = active&(IFM_ETH_RXPAUSE|IFM_FLOW) != 0
+		m.TxPause = active&(IFM_ETH_TXPAUSE|IFM_FLOW) != 0
+	}
+	return m
+}
+
+func ifMediaIoctl(fd int, req uintptr, ifmr *ifMediareq) unix.Errno {
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		req,
+		uintptr(unsafe.Pointer(ifmr)),
+	)
+	return errno
+}
+
+// linkOperState translates the ifi_link_state of if_data.
+func linkOperState(linkState uint8) LinkOperState {
+	switch linkState {
+	case LINK_STATE_UP:
+		return OperUp
+	case LINK_STATE_DOWN:
+		return OperDown
+	default:
+		return OperUnknown
+	}
+}
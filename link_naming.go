@@ -0,0 +1,79 @@
+package netlink
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"path"
+)
+
+// NamingRule gives a link a stable name and configuration based on what
+// identifies its device rather than on probe order. The match fields that
+// are set must all match; Driver, Location and Description are shell
+// patterns as of path.Match. A rule without match fields matches nothing.
+type NamingRule struct {
+	HardwareAddr net.HardwareAddr // MAC address
+	Driver       string           // driver or drivername, e.g. "igb" or "igb1"
+	Location     string           // bus address, e.g. "pci0:3:0:0", or %location
+	Description  string           // description of the link, see LinkSetAlias
+
+	Name   string   // new name of the link
+	Alias  string   // description to set
+	MTU    int      // MTU to set
+	Groups []string // interface groups to add the link to
+}
+
+// linkIdentity is what a NamingRule can match a link on. Driver is looked
+// up with lookupDriver the first time a rule matches on it, if not set.
+type linkIdentity struct {
+	HardwareAddr net.HardwareAddr
+	Driver       *DriverInfo
+	Description  string
+
+	lookupDriver func() (*DriverInfo, error)
+}
+
+// driver returns the driver of the link, nil if it is not known.
+func (id *linkIdentity) driver() *DriverInfo {
+	if id.Driver == nil && id.lookupDriver != nil {
+		id.Driver, _ = id.lookupDriver()
+		id.lookupDriver = nil
+	}
+	return id.Driver
+}
+
+// match reports whether the rule matches the link identified by id.
+func (r *NamingRule) match(id *linkIdentity) bool {
+	if r.HardwareAddr == nil && r.Driver == "" && r.Location == "" && r.Description == "" {
+		return false
+	}
+	if r.HardwareAddr != nil && !bytes.Equal(r.HardwareAddr, id.HardwareAddr) {
+		return false
+	}
+	if r.Description != "" && !matchPattern(r.Description, id.Description) {
+		return false
+	}
+	if r.Driver != "" || r.Location != "" {
+		info := id.driver()
+		if info == nil {
+			return false
+		}
+		drivername := info.Driver
+		if info.Unit >= 0 {
+			drivername = fmt.Sprintf("%s%d", info.Driver, info.Unit)
+		}
+		if r.Driver != "" && !matchPattern(r.Driver, info.Driver) && !matchPattern(r.Driver, drivername) {
+			return false
+		}
+		if r.Location != "" && !matchPattern(r.Location, info.BusInfo) && !matchPattern(r.Location, info.Location) {
+			return false
+		}
+	}
+	return true
+}
+
+// matchPattern is path.Match with malformed patterns matching nothing.
+func matchPattern(pattern, s string) bool {
+	ok, err := path.Match(pattern, s)
+	return err == nil && ok
+}
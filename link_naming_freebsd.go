@@ -0,0 +1,157 @@
+package netlink
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/oss-fun/netlink/nlunix"
+	"github.com/oss-fun/vnet"
+	"golang.org/x/sys/unix"
+)
+
+// ApplyNamingRules applies to every link the first rule that matches it.
+// The changes of a rule are applied all or none, links a rule fails for
+// are left as they are and the others are still processed.
+// Equivalent to: `ifconfig $link mtu $mtu description $alias group $group name $name`
+func ApplyNamingRules(rules []NamingRule) error {
+	return pkgHandle.ApplyNamingRules(rules)
+}
+
+// ApplyNamingRules applies to every link the first rule that matches it.
+// The changes of a rule are applied all or none, links a rule fails for
+// are left as they are and the others are still processed.
+// Equivalent to: `ifconfig $link mtu $mtu description $alias group $group name $name`
+func (h *Handle) ApplyNamingRules(rules []NamingRule) error {
+	links, err := h.LinkList()
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, link := range links {
+		if err := h.applyNamingRules(link, rules); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// NamingRulesSubscribe applies the rules to every link attached from now
+// on until done is closed, as ApplyNamingRules does. With ListExisting the
+// links already present are processed as well. Errors are passed to the
+// ErrorCallback. Rules are applied in the current namespace only.
+func NamingRulesSubscribe(rules []NamingRule, done <-chan struct{}, options LinkSubscribeOptions) error {
+	if options.Namespace != nil && options.Namespace.IsOpen() {
+		return fmt.Errorf("naming rules can only be applied in the current namespace")
+	}
+
+	// Links present before subscribing only get link messages for
+	// changes, they are not new unless they are listed. The subscription
+	// lists the links again once it is subscribed, the ones attached in
+	// between are only in that list.
+	before := make(map[int]bool)
+	if !options.ListExisting {
+		links, err := pkgHandle.linkList()
+		if err != nil {
+			return err
+		}
+		for _, link := range links {
+			before[link.Attrs().Index] = true
+		}
+	}
+
+	seen := make(map[int]bool)
+	ch := make(chan LinkUpdate)
+	err := linkSubscribeAt(vnet.None(), vnet.None(), ch, done, options.ErrorCallback, true, false,
+		options.ReceiveBufferSize, options.ReceiveTimeout, options.ReceiveBufferForceSize)
+	if err != nil {
+		return err
+	}
+	go func() {
+		for update := range ch {
+			index := int(update.Index)
+			switch update.Header.Type {
+			case nlunix.RTM_DELLINK:
+				delete(before, index)
+				delete(seen, index)
+			case nlunix.RTM_NEWLINK:
+				if seen[index] || before[index] {
+					continue
+				}
+				seen[index] = true
+				err := pkgHandle.applyNamingRules(update.Link, rules)
+				if err != nil && options.ErrorCallback != nil {
+					options.ErrorCallback(err)
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+// applyNamingRules applies the first of the rules that matches the link.
+func (h *Handle) applyNamingRules(link Link, rules []NamingRule) error {
+	base := link.Attrs()
+	id := &linkIdentity{
+		HardwareAddr: base.HardwareAddr,
+		Description:  base.Alias,
+		lookupDriver: func() (*DriverInfo, error) { return h.LinkDriverInfo(link) },
+	}
+	for i := range rules {
+		if rules[i].match(id) {
+			return h.applyNamingRule(link, &rules[i])
+		}
+	}
+	return nil
+}
+
+// applyNamingRule applies the MTU, description, groups and name of the
+// rule to the link. If one of them fails, the ones already applied are
+// undone.
+func (h *Handle) applyNamingRule(link Link, rule *NamingRule) (err error) {
+	base := link.Attrs()
+	dev := &Device{LinkAttrs{Name: base.Name}}
+
+	var undo []func()
+	defer func() {
+		if err != nil {
+			for i := len(undo) - 1; i >= 0; i-- {
+				undo[i]()
+			}
+			err = fmt.Errorf("naming rule for %s: %w", base.Name, err)
+		}
+	}()
+
+	if rule.MTU > 0 && rule.MTU != base.MTU {
+		if err = h.LinkSetMTU(dev, rule.MTU); err != nil {
+			return err
+		}
+		mtu := base.MTU
+		undo = append(undo, func() { h.LinkSetMTU(dev, mtu) })
+	}
+	if rule.Alias != "" && rule.Alias != base.Alias {
+		if err = h.LinkSetAlias(dev, rule.Alias); err != nil {
+			return err
+		}
+		alias := base.Alias
+		undo = append(undo, func() { h.LinkSetAlias(dev, alias) })
+	}
+	for _, group := range rule.Groups {
+		if slices.Contains(base.Groups, group) {
+			continue
+		}
+		if err = h.LinkAddGroup(dev, group); err != nil {
+			return err
+		}
+		undo = append(undo, func() { h.LinkDelGroup(dev, group) })
+	}
+	if rule.Name != "" && rule.Name != base.Name {
+		if err = h.LinkSetName(dev, rule.Name); errors.Is(err, unix.EEXIST) {
+			return fmt.Errorf("name %s is taken: %w", rule.Name, err)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
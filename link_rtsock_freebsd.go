@@ -0,0 +1,286 @@
+package netlink
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"unsafe"
+
+	"github.com/oss-fun/netlink/nl"
+	"github.com/oss-fun/netlink/nlunix"
+	netroute "golang.org/x/net/route"
+	"golang.org/x/sys/unix"
+)
+
+// ribLink is a link of the NET_RT_IFLIST sysctl along with the ifinfomsg
+// netlink sends for it.
+type ribLink struct {
+	msg  nl.IfInfomsg
+	link Link
+}
+
+// linksFromRIB returns the links from the NET_RT_IFLIST sysctl, of all
+// links if index is 0. They are built like LinkDeserialize builds the
+// links netlink reports.
+func linksFromRIB(index int) ([]ribLink, error) {
+	rib, err := netroute.FetchRIB(unix.AF_UNSPEC, netroute.RIBTypeInterface, index)
+	if errors.Is(err, unix.ENOENT) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("sysctl NET_RT_IFLIST error: %w", err)
+	}
+	msgs, err := netroute.ParseRIB(netroute.RIBTypeInterface, rib)
+	if err != nil {
+		return nil, err
+	}
+	data, err := linkIfData(index)
+	if err != nil {
+		return nil, err
+	}
+	fd, err := getSocketUDP()
+	if err != nil {
+		return nil, fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+	cloners, err := linkCloners(fd)
+	if err != nil {
+		return nil, err
+	}
+
+	var res []ribLink
+	for _, m := range msgs {
+		ifm, ok := m.(*netroute.InterfaceMessage)
+		if !ok {
+			continue
+		}
+		msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+		msg.Index = int32(ifm.Index)
+
+		base := NewLinkAttrs()
+		base.Index = ifm.Index
+		base.Name = ifm.Name
+		base.RawFlags = uint32(ifm.Flags)
+		if la, ok := ifm.Addrs[unix.RTAX_IFP].(*netroute.LinkAddr); ok && len(la.Addr) > 0 {
+			base.HardwareAddr = net.HardwareAddr(la.Addr)
+		}
+		if d, ok := data[ifm.Index]; ok {
+			// netlink reports the if_type as ifi_type
+			msg.Type = uint16(d.Type)
+			base.MTU = int(d.Mtu)
+			base.OperState = linkOperState(d.LinkState)
+			base.Statistics = d.statistics()
+		}
+		// netlink reports the description as IFLA_IFALIAS
+		if alias, err := linkDescription(fd, ifm.Name); err == nil {
+			base.Alias = alias
+		}
+		base.EncapType = msg.EncapType()
+		base.Flags = linkFlags(base.RawFlags)
+		if base.RawFlags&unix.IFF_ALLMULTI != 0 {
+			base.Allmulti = 1
+		}
+		if base.RawFlags&unix.IFF_MULTICAST != 0 {
+			base.Multi = 1
+		}
+		msg.Flags = base.RawFlags
+
+		// Only cloned links have an IFLA_INFO_KIND, their cloner.
+		var link Link = &Device{}
+		if drivername, err := linkDriverName(ifm.Index); err == nil {
+			if driver, _ := splitDriverName(drivername); cloners[driver] {
+				link = linkOfKind(driver)
+			}
+		}
+		setLinkAttrs(link, base, nil)
+		res = append(res, ribLink{msg: *msg, link: link})
+	}
+	return res, nil
+}
+
+// linkCloners returns the names of the interface cloners using
+// SIOCIFGCLONERS.
+func linkCloners(fd int) (map[string]bool, error) {
+	var req ifCloneReq
+	if errno := ifCloneIoctl(fd, &req); errno != 0 {
+		return nil, fmt.Errorf("ioctl SIOCIFGCLONERS error: %w", errno)
+	}
+	cloners := make(map[string]bool)
+	if req.Total == 0 {
+		return cloners, nil
+	}
+	buf := make([]byte, int(req.Total)*unix.IFNAMSIZ)
+	req.Count = req.Total
+	req.Buffer = unsafe.Pointer(&buf[0])
+	if errno := ifCloneIoctl(fd, &req); errno != 0 {
+		return nil, fmt.Errorf("ioctl SIOCIFGCLONERS error: %w", errno)
+	}
+	// cloners added in between do not fit and are left out
+	for i := 0; i < int(min(req.Count, req.Total)); i++ {
+		cloners[nl.BytesToString(buf[i*unix.IFNAMSIZ:(i+1)*unix.IFNAMSIZ])] = true
+	}
+	return cloners, nil
+}
+
+func ifCloneIoctl(fd int, req *ifCloneReq) unix.Errno {
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(unix.SIOCIFGCLONERS),
+		uintptr(unsafe.Pointer(req)),
+	)
+	return errno
+}
+
+// linkListRIB is linkList without netlink, in the current vnet.
+func linkListRIB() ([]Link, error) {
+	links, err := linksFromRIB(0)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]Link, 0, len(links))
+	for _, l := range links {
+		res = append(res, l.link)
+	}
+	return res, nil
+}
+
+// linkByIndexRIB is linkByIndex without netlink, in the current vnet.
+func linkByIndexRIB(index int) (Link, error) {
+	if index <= 0 {
+		return nil, LinkNotFoundError{fmt.Errorf("Link not found")}
+	}
+	links, err := linksFromRIB(index)
+	if err != nil {
+		return nil, err
+	}
+	if len(links) == 0 {
+		return nil, LinkNotFoundError{fmt.Errorf("Link not found")}
+	}
+	return links[0].link, nil
+}
+
+// linkByNameRIB is linkByName without netlink, in the current vnet.
+func linkByNameRIB(name string) (Link, error) {
+	fd, err := getSocketUDP()
+	if err != nil {
+		return nil, fmt.Errorf("socket error: %w", err)
+	}
+	index, err := linkIndexByName(fd, name)
+	unix.Close(fd)
+	if err != nil {
+		return nil, LinkNotFoundError{fmt.Errorf("Link %s not found", name)}
+	}
+	return linkByIndexRIB(index)
+}
+
+// linkSubscribeRouteSocket is linkSubscribeAt for the current namespace
+// without netlink. The updates are built from RTM_IFINFO and
+// RTM_IFANNOUNCE routing messages.
+func linkSubscribeRouteSocket(ch chan<- LinkUpdate, done <-chan struct{}, cberr func(error), listExisting, markListed bool,
+	rcvbuf int, rcvTimeout *unix.Timeval) error {
+	s, err := openRouteSocket(unix.AF_UNSPEC, done, rcvbuf, rcvTimeout)
+	if err != nil {
+		return err
+	}
+	var existing []ribLink
+	if listExisting {
+		if existing, err = linksFromRIB(0); err != nil {
+			s.Close()
+			return err
+		}
+	}
+	go func() {
+		defer close(ch)
+		defer s.Close()
+		for _, l := range existing {
+			update := LinkUpdate{IfInfomsg: l.msg, Header: nlunix.NlMsghdr{Type: nlunix.RTM_NEWLINK}, Link: l.link}
+			fillUpdateGroups(&update)
+			ch <- update
+		}
+		if markListed {
+			ch <- LinkUpdate{Header: nlunix.NlMsghdr{Type: nlunix.NLMSG_DONE}}
+		}
+		for {
+			b, err := s.Receive()
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				if cberr != nil {
+					cberr(fmt.Errorf("Receive failed: %v", err))
+				}
+				return
+			}
+			update, err := linkUpdateFromRouteMessage(b)
+			if err != nil {
+				if cberr != nil {
+					cberr(err)
+				}
+				continue
+			}
+			if update != nil {
+				fillUpdateGroups(update)
+				ch <- *update
+			}
+		}
+	}()
+	return nil
+}
+
+// linkUpdateFromRouteMessage returns the link update for a routing
+// message, nil if it is not about a link or the link is gone already.
+func linkUpdateFromRouteMessage(b []byte) (*LinkUpdate, error) {
+	switch b[3] {
+	case unix.RTM_IFINFO:
+		// the index follows the addrs and flags of if_msghdr
+		if len(b) < 14 {
+			return nil, fmt.Errorf("invalid RTM_IFINFO message length %d", len(b))
+		}
+		return linkUpdateByIndex(int(native.Uint16(b[12:14])))
+	case unix.RTM_IFANNOUNCE:
+		msgs, err := netroute.ParseRIB(netroute.RIBTypeRoute, b)
+		if err != nil {
+			return nil, err
+		}
+		for _, m := range msgs {
+			ifan, ok := m.(*netroute.InterfaceAnnounceMessage)
+			if !ok {
+				continue
+			}
+			switch ifan.What {
+			case unix.IFAN_ARRIVAL:
+				return linkUpdateByIndex(ifan.Index)
+			case unix.IFAN_DEPARTURE:
+				// the link is gone, only its index and name are known
+				msg := nl.NewIfInfomsg(unix.AF_UNSPEC)
+				msg.Index = int32(ifan.Index)
+				base := NewLinkAttrs()
+				base.Index = ifan.Index
+				base.Name = ifan.Name
+				return &LinkUpdate{
+					IfInfomsg: *msg,
+					Header:    nlunix.NlMsghdr{Type: nlunix.RTM_DELLINK},
+					Link:      &Device{LinkAttrs: base},
+				}, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+// linkUpdateByIndex returns the RTM_NEWLINK update for the link index, nil
+// if it is gone.
+func linkUpdateByIndex(index int) (*LinkUpdate, error) {
+	links, err := linksFromRIB(index)
+	if err != nil || len(links) == 0 {
+		return nil, err
+	}
+	l := links[0]
+	return &LinkUpdate{
+		IfInfomsg: l.msg,
+		Header:    nlunix.NlMsghdr{Type: nlunix.RTM_NEWLINK},
+		Link:      l.link,
+	}, nil
+}
@@ -9,9 +9,12 @@ import (
 	"fmt"
 	"net"
 	"os/exec"
+	"strings"
 	"testing"
 	"time"
+	"unsafe"
 
+	"github.com/oss-fun/netlink/nlunix"
 	"github.com/oss-fun/vnet"
 	"golang.org/x/sys/unix"
 )
@@ -1263,6 +1266,171 @@ func TestLinkAddDelBridgeMaster(t *testing.T) {
 	}
 }
 
+func TestLinkSetMaster(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	if err := LinkAdd(&Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}); err != nil {
+		t.Fatal(err)
+	}
+	master, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(master)
+
+	veth := &Veth{LinkAttrs: LinkAttrs{Name: "bar"}, PeerName: "baz"}
+	if err := LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(veth)
+
+	if err := LinkSetMaster(veth, master); err != nil {
+		t.Fatal(err)
+	}
+	link, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().MasterIndex != master.Attrs().Index {
+		t.Fatalf("master index is %d, should be %d", link.Attrs().MasterIndex, master.Attrs().Index)
+	}
+
+	links, err := LinkList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, l := range links {
+		switch l.Attrs().Name {
+		case "bar":
+			if l.Attrs().MasterIndex != master.Attrs().Index {
+				t.Fatalf("listed master index is %d, should be %d", l.Attrs().MasterIndex, master.Attrs().Index)
+			}
+		case "baz":
+			if l.Attrs().MasterIndex != 0 {
+				t.Fatalf("peer has master index %d", l.Attrs().MasterIndex)
+			}
+		}
+	}
+
+	if err := LinkSetNoMaster(veth); err != nil {
+		t.Fatal(err)
+	}
+	if link, err = LinkByName("bar"); err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().MasterIndex != 0 {
+		t.Fatalf("master index is %d after nomaster, should be 0", link.Attrs().MasterIndex)
+	}
+
+	if err := LinkSetMaster(veth, &Veth{LinkAttrs: LinkAttrs{Name: "baz"}}); err == nil {
+		t.Fatal("non bridge master accepted")
+	}
+}
+
+func TestLinkSetBridgePortFlags(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	master := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}
+	if err := LinkAdd(master); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(master)
+	veth := &Veth{LinkAttrs: LinkAttrs{Name: "bar"}, PeerName: "baz"}
+	if err := LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(veth)
+
+	if _, err := LinkGetProtinfo(veth); err == nil {
+		t.Fatal("got protinfo of a link without master")
+	}
+	if err := LinkSetMaster(veth, master); err != nil {
+		t.Fatal(err)
+	}
+
+	pi, err := LinkGetProtinfo(veth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !pi.Learning || !pi.Flood || pi.Isolated || pi.Stp || pi.Edge || pi.Sticky {
+		t.Fatalf("unexpected default flags %s", pi.String())
+	}
+
+	for _, set := range []func(Link, bool) error{
+		LinkSetLearning, LinkSetFlood,
+	} {
+		if err := set(veth, false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for _, set := range []func(Link, bool) error{
+		LinkSetIsolated, LinkSetBrStp, LinkSetBrEdge, LinkSetBrSticky,
+	} {
+		if err := set(veth, true); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if pi, err = LinkGetProtinfo(veth); err != nil {
+		t.Fatal(err)
+	}
+	if pi.Learning || pi.Flood || !pi.Isolated || !pi.Stp || !pi.Edge || !pi.Sticky {
+		t.Fatalf("unexpected flags %s", pi.String())
+	}
+
+	link, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().Protinfo == nil || link.Attrs().Protinfo.String() != pi.String() {
+		t.Fatalf("protinfo is %s, should be %s", link.Attrs().Protinfo, pi.String())
+	}
+}
+
+func TestVethPeerLocation(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	basens, err := vnet.Get()
+	if err != nil {
+		t.Fatal("Failed to get basens")
+	}
+	defer basens.Close()
+
+	newns, err := vnet.New()
+	if err != nil {
+		t.Fatal("Failed to create newns")
+	}
+	defer newns.Close()
+
+	link := &Veth{LinkAttrs{Name: "foo"}, "bar", nil, NsFd(basens)}
+	if err := LinkAdd(link); err != nil {
+		t.Fatal(err)
+	}
+
+	// lookups stay in the current vnet
+	foo, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if veth := foo.(*Veth); veth.PeerName != "" || veth.PeerNamespace != nil {
+		t.Fatalf("peer %s in %v found by LinkByName", veth.PeerName, veth.PeerNamespace)
+	}
+
+	name, jail, err := VethPeerLocation(link, basens)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if name != "bar" || jail != basens {
+		t.Fatalf("expected peer bar in %d got %s in %d", basens, name, jail)
+	}
+	if _, _, err := VethPeerLocation(link); err == nil {
+		t.Fatal("peer found without searching basens")
+	}
+}
+
 func TestLinkSetNs(t *testing.T) {
 	tearDown := setUpNetlinkTest(t)
 	defer tearDown()
@@ -1888,6 +2056,103 @@ func TestLinkStats(t *testing.T) {
 	}
 }
 
+func TestLinkStatsOnly(t *testing.T) {
+	defer setUpNetlinkTest(t)()
+
+	vethLink := &Veth{LinkAttrs: LinkAttrs{Name: "v0"}, PeerName: "v1"}
+	if err := LinkAdd(vethLink); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(vethLink)
+	for _, name := range []string{"v0", "v1"} {
+		link, err := LinkByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := LinkSetUp(link); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	time.Sleep(2 * time.Second)
+
+	veth0, err := LinkByName("v0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if veth0.Attrs().Statistics == nil {
+		t.Fatal("no statistics reported")
+	}
+	stats, err := LinkStatsOnly(veth0.Attrs().Index)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.TxPackets < veth0.Attrs().Statistics.TxPackets {
+		t.Fatalf("tx packets went back from %d to %d", veth0.Attrs().Statistics.TxPackets, stats.TxPackets)
+	}
+
+	if _, err := LinkStatsOnly(0x7fff); err == nil {
+		t.Fatal("got statistics of a missing link")
+	}
+}
+
+func TestLinkIfDataByName(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	lo, err := LinkByName("lo0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(lo); err != nil {
+		t.Fatal(err)
+	}
+	if err := AddrAdd(lo, &Addr{IPNet: &net.IPNet{IP: net.IPv4(127, 0, 0, 1), Mask: net.CIDRMask(8, 32)}}); err != nil && !errors.Is(err, unix.EEXIST) {
+		t.Fatal(err)
+	}
+	conn, err := net.Dial("udp4", "127.0.0.1:9")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := conn.Write([]byte("ping")); err != nil {
+		t.Fatal(err)
+	}
+	conn.Close()
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unix.Close(fd)
+	data, err := linkIfDataByName(fd, "lo0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	stats := data.statistics()
+	if stats.TxPackets == 0 || stats.RxPackets == 0 || stats.TxBytes == 0 || stats.RxBytes == 0 {
+		t.Fatalf("expected nonzero counters on lo0 got %+v", stats)
+	}
+	if stats.TxMulticast != data.Omcasts {
+		t.Fatalf("TxMulticast is %d, should be %d", stats.TxMulticast, data.Omcasts)
+	}
+
+	// the fallback used when netlink reports no statistics
+	dev := &Device{LinkAttrs{Name: "lo0"}}
+	fillIoctlAttrs(fd, dev)
+	if dev.Statistics == nil || dev.Statistics.TxPackets == 0 {
+		t.Fatalf("expected statistics from if_data got %+v", dev.Statistics)
+	}
+}
+
+func TestIfDataSize(t *testing.T) {
+	if size := unsafe.Sizeof(ifData{}); size != 152 {
+		t.Fatalf("sizeof(struct if_data) is %d, should be 152", size)
+	}
+	if size := unsafe.Sizeof(ifMsghdrl{}); size != 24 {
+		t.Fatalf("sizeof(struct if_msghdrl) without if_data is %d, should be 24", size)
+	}
+}
+
 
 func TestLinkAddDelIptun(t *testing.T) {
 	minKernelRequired(t, 4, 9)
@@ -2127,6 +2392,138 @@ func TestBridgeCreationWithHelloTime(t *testing.T) {
 	}
 }
 
+func TestBridgeCreationWithStpParams(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	proto := BRIDGE_STP_PROTO_STP
+	priority := uint16(4096)
+	forwardDelay := uint32(1000)
+	maxAge := uint32(1500)
+	maxAddresses := uint32(500)
+	bridge := &Bridge{
+		LinkAttrs:    LinkAttrs{Name: "foo"},
+		StpProtocol:  &proto,
+		Priority:     &priority,
+		ForwardDelay: &forwardDelay,
+		MaxAge:       &maxAge,
+		MaxAddresses: &maxAddresses,
+	}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(bridge)
+
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	retrieved := link.(*Bridge)
+	if retrieved.StpProtocol == nil || *retrieved.StpProtocol != proto {
+		t.Fatalf("expected protocol %s got %v", proto, retrieved.StpProtocol)
+	}
+	if retrieved.Priority == nil || *retrieved.Priority != priority {
+		t.Fatalf("expected priority %d got %v", priority, retrieved.Priority)
+	}
+	if retrieved.ForwardDelay == nil || *retrieved.ForwardDelay != forwardDelay {
+		t.Fatalf("expected forward delay %d got %v", forwardDelay, retrieved.ForwardDelay)
+	}
+	if retrieved.MaxAge == nil || *retrieved.MaxAge != maxAge {
+		t.Fatalf("expected max age %d got %v", maxAge, retrieved.MaxAge)
+	}
+	if retrieved.MaxAddresses == nil || *retrieved.MaxAddresses != maxAddresses {
+		t.Fatalf("expected max addresses %d got %v", maxAddresses, retrieved.MaxAddresses)
+	}
+}
+
+func TestBridgeModifyMTUAndStpParams(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(bridge)
+
+	forwardDelay := uint32(1000)
+	maxAge := uint32(1500)
+	bridge.MTU = 1400
+	bridge.ForwardDelay = &forwardDelay
+	bridge.MaxAge = &maxAge
+	if err := LinkModify(bridge); err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().MTU != 1400 {
+		t.Fatalf("expected MTU 1400 got %d", link.Attrs().MTU)
+	}
+	retrieved := link.(*Bridge)
+	if retrieved.ForwardDelay == nil || *retrieved.ForwardDelay != forwardDelay {
+		t.Fatalf("expected forward delay %d got %v", forwardDelay, retrieved.ForwardDelay)
+	}
+	if retrieved.MaxAge == nil || *retrieved.MaxAge != maxAge {
+		t.Fatalf("expected max age %d got %v", maxAge, retrieved.MaxAge)
+	}
+}
+
+func TestBridgeModifySpanPorts(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(bridge)
+	veth := &Veth{LinkAttrs: LinkAttrs{Name: "bar"}, PeerName: "baz"}
+	if err := LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(veth)
+
+	ageingTime := uint32(6000)
+	bridge.AgeingTime = &ageingTime
+	bridge.SpanPorts = []string{"bar"}
+	if err := LinkModify(bridge); err != nil {
+		t.Fatal(err)
+	}
+
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	retrieved := link.(*Bridge)
+	if *retrieved.AgeingTime != ageingTime {
+		t.Fatalf("expected %d got %d", ageingTime, *retrieved.AgeingTime)
+	}
+	if len(retrieved.SpanPorts) != 1 || retrieved.SpanPorts[0] != "bar" {
+		t.Fatalf("expected span ports [bar] got %v", retrieved.SpanPorts)
+	}
+	if link, err = LinkByName("bar"); err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().MasterIndex != 0 {
+		t.Fatal("span port reported as bridge member")
+	}
+
+	bridge.AgeingTime = nil
+	bridge.SpanPorts = []string{}
+	if err := LinkModify(bridge); err != nil {
+		t.Fatal(err)
+	}
+	if link, err = LinkByName("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if len(link.(*Bridge).SpanPorts) != 0 {
+		t.Fatalf("expected no span ports got %v", link.(*Bridge).SpanPorts)
+	}
+}
+
 func TestBridgeCreationWithVlanFiltering(t *testing.T) {
 	minKernelRequired(t, 3, 18)
 
@@ -2243,47 +2640,99 @@ func TestLinkByNameWhenLinkIsNotFound(t *testing.T) {
 	}
 }
 
-func TestVethPeerIndex(t *testing.T) {
+func TestLinkDelByIndex(t *testing.T) {
 	tearDown := setUpNetlinkTest(t)
 	defer tearDown()
 
-	const (
-		vethPeer1 = "vethOne"
-		vethPeer2 = "vethTwo"
-	)
-
-	link := &Veth{
-		LinkAttrs: LinkAttrs{
-			Name:  vethPeer1,
-			MTU:   1500,
-			Flags: net.FlagUp,
-		},
-		PeerName: vethPeer2,
-	}
-
-	if err := LinkAdd(link); err != nil {
+	if err := LinkAdd(&Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}); err != nil {
 		t.Fatal(err)
 	}
-
-	linkOne, err := LinkByName("vethOne")
+	link, err := LinkByName("foo")
 	if err != nil {
 		t.Fatal(err)
 	}
 
-	linkTwo, err := LinkByName("vethTwo")
-	if err != nil {
+	if err := LinkDel(&Bridge{LinkAttrs: LinkAttrs{Index: link.Attrs().Index}}); err != nil {
 		t.Fatal(err)
 	}
-
-	peerIndexOne, err := VethPeerIndex(&Veth{LinkAttrs: *linkOne.Attrs()})
-	if err != nil {
-		t.Fatal(err)
+	if _, err := LinkByName("foo"); err == nil {
+		t.Fatal("Link not removed properly")
 	}
+}
 
-	peerIndexTwo, err := VethPeerIndex(&Veth{LinkAttrs: *linkTwo.Attrs()})
-	if err != nil {
-		t.Fatal(err)
-	}
+func TestLinkDelNotFound(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	err := LinkDel(&Bridge{LinkAttrs: LinkAttrs{Name: "iamnotexist"}})
+	if err == nil {
+		t.Fatal("Link not expected to be deleted")
+	}
+	if _, ok := err.(LinkNotFoundError); !ok {
+		t.Errorf("Error returned expected to of LinkNotFoundError type: %v", err)
+	}
+}
+
+func TestLinkDelVethPeer(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Only the peer is known, both ends must be gone afterwards.
+	if err := LinkDel(&Veth{LinkAttrs: LinkAttrs{Name: "missing"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"foo", "bar"} {
+		if _, err := LinkByName(name); err == nil {
+			t.Fatalf("Link %s not removed properly", name)
+		}
+	}
+}
+
+func TestVethPeerIndex(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	const (
+		vethPeer1 = "vethOne"
+		vethPeer2 = "vethTwo"
+	)
+
+	link := &Veth{
+		LinkAttrs: LinkAttrs{
+			Name:  vethPeer1,
+			MTU:   1500,
+			Flags: net.FlagUp,
+		},
+		PeerName: vethPeer2,
+	}
+
+	if err := LinkAdd(link); err != nil {
+		t.Fatal(err)
+	}
+
+	linkOne, err := LinkByName("vethOne")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	linkTwo, err := LinkByName("vethTwo")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peerIndexOne, err := VethPeerIndex(&Veth{LinkAttrs: *linkOne.Attrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	peerIndexTwo, err := VethPeerIndex(&Veth{LinkAttrs: *linkTwo.Attrs()})
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	if peerIndexOne != linkTwo.Attrs().Index {
 		t.Errorf("VethPeerIndex(%s) mismatch %d != %d", linkOne.Attrs().Name, peerIndexOne, linkTwo.Attrs().Index)
@@ -2292,5 +2741,783 @@ func TestVethPeerIndex(t *testing.T) {
 	if peerIndexTwo != linkOne.Attrs().Index {
 		t.Errorf("VethPeerIndex(%s) mismatch %d != %d", linkTwo.Attrs().Name, peerIndexTwo, linkOne.Attrs().Index)
 	}
+
+	// the peer is found from the kernel, not from the names
+	if err := LinkSetName(linkTwo, "foo"); err != nil {
+		t.Fatal(err)
+	}
+	renamed, err := LinkByName(vethPeer1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	veth, ok := renamed.(*Veth)
+	if !ok {
+		t.Fatalf("unexpected link type %T", renamed)
+	}
+	if veth.PeerName != "foo" {
+		t.Fatalf("unexpected peer name %q", veth.PeerName)
+	}
+}
+
+func TestVethAttrs(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	hwaddr, _ := net.ParseMAC("02:00:00:00:00:0a")
+	peerHwaddr, _ := net.ParseMAC("02:00:00:00:00:0b")
+	link := &Veth{
+		LinkAttrs:        LinkAttrs{Name: "foo", MTU: 1400, HardwareAddr: hwaddr},
+		PeerName:         "bar",
+		PeerHardwareAddr: peerHwaddr,
+	}
+	if err := LinkAdd(link); err != nil {
+		t.Fatal(err)
+	}
+
+	for name, want := range map[string]net.HardwareAddr{"foo": hwaddr, "bar": peerHwaddr} {
+		l, err := LinkByName(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if l.Attrs().MTU != 1400 {
+			t.Fatalf("%s: unexpected mtu %d", name, l.Attrs().MTU)
+		}
+		if !bytes.Equal(l.Attrs().HardwareAddr, want) {
+			t.Fatalf("%s: unexpected hardware address %s", name, l.Attrs().HardwareAddr)
+		}
+	}
+
+	// a multicast address fails after the epair is created
+	multicast, _ := net.ParseMAC("01:00:00:00:00:0b")
+	link = &Veth{
+		LinkAttrs:        LinkAttrs{Name: "baz"},
+		PeerName:         "qux",
+		PeerHardwareAddr: multicast,
+	}
+	if err := LinkAdd(link); err == nil {
+		t.Fatal("LinkAdd with a multicast peer address succeeded")
+	}
+	if _, err := LinkByName("baz"); err == nil {
+		t.Fatal("epair not rolled back")
+	}
+}
+
+func TestLinkCapabilityString(t *testing.T) {
+	if s := (IFCAP_RXCSUM | IFCAP_VLAN_MTU | IFCAP_RXTLS4).String(); s != "RXCSUM,VLAN_MTU,RXTLS4" {
+		t.Fatalf("unexpected capability string %q", s)
+	}
+	if s := LinkCapability(0).String(); s != "" {
+		t.Fatalf("unexpected capability string %q", s)
+	}
+}
+
+func TestLinkSetCapabilities(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	veth := &Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}
+	if err := LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(veth)
+
+	caps, err := LinkGetCapabilities(veth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if caps.Enabled&^caps.Supported != 0 {
+		t.Fatalf("unsupported capabilities enabled: %s", caps.Enabled&^caps.Supported)
+	}
+	var c LinkCapability
+	for _, try := range []LinkCapability{IFCAP_RXCSUM, IFCAP_TXCSUM, IFCAP_VLAN_MTU} {
+		if caps.Supported&try != 0 {
+			c = try
+			break
+		}
+	}
+	if c == 0 {
+		t.Skipf("no toggleable capability in %s", caps.Supported)
+	}
+
+	if err := LinkSetCapabilities(veth, 0, c); err != nil {
+		t.Fatal(err)
+	}
+	if caps, err = LinkGetCapabilities(veth); err != nil {
+		t.Fatal(err)
+	}
+	if caps.Enabled&c != 0 {
+		t.Fatalf("capability %s not disabled", c)
+	}
+	if err := LinkSetCapabilities(veth, c, 0); err != nil {
+		t.Fatal(err)
+	}
+	if caps, err = LinkGetCapabilities(veth); err != nil {
+		t.Fatal(err)
+	}
+	if caps.Enabled&c == 0 {
+		t.Fatalf("capability %s not enabled", c)
+	}
+}
+
+func TestLinkSetAlias(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "foo", Alias: "uplink-a"}}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(bridge)
+
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().Alias != "uplink-a" {
+		t.Fatalf("expected alias uplink-a got %q", link.Attrs().Alias)
+	}
+
+	if err := LinkSetAlias(link, "uplink-b"); err != nil {
+		t.Fatal(err)
+	}
+	links, err := LinkList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	found := false
+	for _, l := range links {
+		if l.Attrs().Name == "foo" {
+			found = l.Attrs().Alias == "uplink-b"
+		}
+	}
+	if !found {
+		t.Fatal("alias uplink-b not in LinkList")
+	}
+
+	if err := LinkSetAlias(link, strings.Repeat("a", 1<<16)); err == nil {
+		t.Fatal("oversized alias accepted")
+	}
+
+	if err := LinkSetAlias(link, ""); err != nil {
+		t.Fatal(err)
+	}
+	if link, err = LinkByName("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().Alias != "" {
+		t.Fatalf("alias not removed: %q", link.Attrs().Alias)
+	}
+}
+
+func TestLinkGroups(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	ch := make(chan LinkUpdate)
+	done := make(chan struct{})
+	defer close(done)
+	if err := LinkSubscribe(ch, done); err != nil {
+		t.Fatal(err)
+	}
+
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "foo", Groups: []string{"uplink"}}}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(bridge)
+
+	hasGroup := func(groups []string, group string) bool {
+		for _, g := range groups {
+			if g == group {
+				return true
+			}
+		}
+		return false
+	}
+
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !hasGroup(link.Attrs().Groups, "uplink") || !hasGroup(link.Attrs().Groups, "bridge") {
+		t.Fatalf("expected groups uplink and bridge got %v", link.Attrs().Groups)
+	}
+	if hasGroup(link.Attrs().Groups, IFG_ALL) {
+		t.Fatalf("group %s reported", IFG_ALL)
+	}
+
+	if err := LinkAddGroup(link, "edge"); err != nil {
+		t.Fatal(err)
+	}
+	// the new group shows in the next message about the link
+	if err := LinkSetUp(link); err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(time.Minute)
+	for found := false; !found; {
+		select {
+		case update := <-ch:
+			found = update.Attrs().Name == "foo" && hasGroup(update.Attrs().Groups, "edge")
+		case <-timeout:
+			t.Fatal("no update for group edge")
+		}
+	}
+
+	links, err := LinkListByGroup("edge")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 1 || links[0].Attrs().Name != "foo" {
+		t.Fatalf("expected foo in group edge got %v", links)
+	}
+
+	if err := LinkDelGroup(link, "edge"); err != nil {
+		t.Fatal(err)
+	}
+	groups, err := LinkGroups(link)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hasGroup(groups, "edge") {
+		t.Fatalf("group edge not removed: %v", groups)
+	}
+	if links, err = LinkListByGroup("edge"); err != nil {
+		t.Fatal(err)
+	}
+	if len(links) != 0 {
+		t.Fatalf("expected empty group edge got %v", links)
+	}
+}
+
+func TestLinkSetFib(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	fibs, err := unix.SysctlUint32("net.fibs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fibs < 2 {
+		t.Skip("needs net.fibs of at least 2")
+	}
+
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(bridge)
+
+	if err := LinkSetMaster(bridge, &Vrf{LinkAttrs: LinkAttrs{Name: "red"}, Table: 1}); err != nil {
+		t.Fatal(err)
+	}
+	fib, err := LinkGetFib(bridge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if fib != 1 {
+		t.Fatalf("expected fib 1 got %d", fib)
+	}
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().Fib != 1 {
+		t.Fatalf("expected fib 1 in LinkAttrs got %d", link.Attrs().Fib)
+	}
+	if slave, ok := link.Attrs().Slave.(*VrfSlave); !ok || slave.Table != 1 {
+		t.Fatalf("expected vrf slave of table 1 got %v", link.Attrs().Slave)
+	}
+
+	if err := LinkSetFib(bridge, int(fibs)); err == nil {
+		t.Fatalf("fib %d accepted", fibs)
+	}
+	if err := LinkSetFib(bridge, 0); err != nil {
+		t.Fatal(err)
+	}
+	if link, err = LinkByName("foo"); err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().Fib != 0 || link.Attrs().Slave != nil {
+		t.Fatalf("link not back in fib 0: %d %v", link.Attrs().Fib, link.Attrs().Slave)
+	}
+
+	// leaving a Vrf resets the FIB
+	if err := LinkSetMaster(bridge, &Vrf{LinkAttrs: LinkAttrs{Name: "red"}, Table: 1}); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetNoMaster(bridge); err != nil {
+		t.Fatal(err)
+	}
+	if fib, err = LinkGetFib(bridge); err != nil {
+		t.Fatal(err)
+	}
+	if fib != 0 {
+		t.Fatalf("expected fib 0 after nomaster got %d", fib)
+	}
+
+	// an explicit FIB is kept
+	if err := LinkSetFib(bridge, 1); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetNoMaster(bridge); err != nil {
+		t.Fatal(err)
+	}
+	if fib, err = LinkGetFib(bridge); err != nil {
+		t.Fatal(err)
+	}
+	if fib != 1 {
+		t.Fatalf("expected fib 1 kept after nomaster got %d", fib)
+	}
+}
+
+func TestLinkSetFlags(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(bridge)
+
+	expectFlags := func(set, clear uint32) Link {
+		t.Helper()
+		link, err := LinkByName("foo")
+		if err != nil {
+			t.Fatal(err)
+		}
+		raw := link.Attrs().RawFlags
+		if raw&set != set || raw&clear != 0 {
+			t.Fatalf("flags %#x: expected %#x set and %#x clear", raw, set, clear)
+		}
+		return link
+	}
+
+	if err := LinkSetUp(bridge); err != nil {
+		t.Fatal(err)
+	}
+	if link := expectFlags(unix.IFF_UP, 0); link.Attrs().Flags&net.FlagUp == 0 {
+		t.Fatal("net.FlagUp not set")
+	}
+
+	if err := LinkSetPromiscOn(bridge); err != nil {
+		t.Fatal(err)
+	}
+	if link := expectFlags(unix.IFF_PPROMISC|unix.IFF_PROMISC, 0); link.Attrs().Promisc != 1 {
+		t.Fatal("Promisc not set")
+	}
+	if err := LinkSetPromiscOff(bridge); err != nil {
+		t.Fatal(err)
+	}
+	expectFlags(0, unix.IFF_PPROMISC)
+
+	flags := uint32(unix.IFF_NOARP | unix.IFF_STATICARP | unix.IFF_MONITOR | unix.IFF_DEBUG |
+		unix.IFF_LINK0 | unix.IFF_LINK1 | unix.IFF_LINK2)
+	if err := LinkSetFlags(bridge, flags, 0); err != nil {
+		t.Fatal(err)
+	}
+	expectFlags(flags, 0)
+	if err := LinkSetFlags(bridge, 0, flags); err != nil {
+		t.Fatal(err)
+	}
+	expectFlags(0, flags)
+
+	if err := LinkSetARPOff(bridge); err != nil {
+		t.Fatal(err)
+	}
+	expectFlags(unix.IFF_NOARP, 0)
+	if err := LinkSetARPOn(bridge); err != nil {
+		t.Fatal(err)
+	}
+	expectFlags(0, unix.IFF_NOARP)
+
+	if err := LinkSetFlags(bridge, 0, unix.IFF_MULTICAST); err == nil {
+		t.Fatal("IFF_MULTICAST cleared")
+	}
+	if err := LinkSetDown(bridge); err != nil {
+		t.Fatal(err)
+	}
+	expectFlags(0, unix.IFF_UP)
+}
+
+func TestMediaParse(t *testing.T) {
+	m, err := ParseMedia(IFM_ETHER, "1000baseT", []string{"full-duplex", "master"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m != IFM_ETHER|16|IFM_FDX|IFM_ETH_MASTER {
+		t.Fatalf("unexpected media word %#x", uint32(m))
+	}
+	if s := m.String(); s != "1000baseT <full-duplex,master>" {
+		t.Fatalf("unexpected media string %q", s)
+	}
+	if m.Speed() != 1000 {
+		t.Fatalf("expected speed 1000 got %d", m.Speed())
+	}
+
+	// extended subtypes are split over the subtype and xtype bits
+	if m, err = ParseMedia(IFM_ETHER, "25gbase-sr", nil); err != nil {
+		t.Fatal(err)
+	}
+	if m.Subtype() != 55 || m.Name() != "25GBase-SR" || m.Speed() != 25000 {
+		t.Fatalf("unexpected media %s subtype %d", m, m.Subtype())
+	}
+
+	if _, err := ParseMedia(IFM_ETHER, "1000baseT", []string{"bogus"}); err == nil {
+		t.Fatal("bogus option accepted")
+	}
+	if _, err := ParseMedia(IFM_ETHER, "bogus", nil); err == nil {
+		t.Fatal("bogus media accepted")
+	}
+}
+
+func TestLinkMedia(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	veth := &Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}
+	if err := LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(veth)
+	if err := LinkSetUp(veth); err != nil {
+		t.Fatal(err)
+	}
+	if err := LinkSetUp(&Veth{LinkAttrs: LinkAttrs{Name: "bar"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	media, err := LinkGetMedia(veth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if media.Current.Type() != IFM_ETHER || len(media.Supported) == 0 {
+		t.Fatalf("unexpected media %+v", media)
+	}
+	if media.Status&IFM_AVALID != 0 && media.Speed == 0 {
+		t.Fatalf("no speed for active media %s", media.Active)
+	}
+
+	sup := media.Supported[0]
+	if err := LinkSetMedia(veth, sup.Name(), sup.Options()); err != nil {
+		t.Fatal(err)
+	}
+	if media, err = LinkGetMedia(veth); err != nil {
+		t.Fatal(err)
+	}
+	if media.Current != sup {
+		t.Fatalf("expected media %s got %s", sup, media.Current)
+	}
+
+	link, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if link.Attrs().OperState != OperUp {
+		t.Fatalf("expected operstate up got %s", link.Attrs().OperState)
+	}
+}
+
+func TestSplitDriverName(t *testing.T) {
+	for _, tc := range []struct {
+		in     string
+		driver string
+		unit   int
+	}{
+		{"ixl0", "ixl", 0},
+		{"mlx5_core12", "mlx5_core", 12},
+		{"lo", "lo", -1},
+		{"pflog-1", "pflog", -1},
+	} {
+		driver, unit := splitDriverName(tc.in)
+		if driver != tc.driver || unit != tc.unit {
+			t.Errorf("splitDriverName(%q) = %q, %d, want %q, %d", tc.in, driver, unit, tc.driver, tc.unit)
+		}
+	}
+	if bus := deviceBusInfo("slot=0 function=0 dbsf=pci0:3:0:0 handle=\\_SB_.PCI0"); bus != "pci0:3:0:0" {
+		t.Fatalf("unexpected bus info %q", bus)
+	}
+}
+
+func TestLinkDriverInfo(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	veth := &Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}
+	if err := LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(veth)
+
+	info, err := LinkDriverInfo(veth)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Driver != "epair" || info.Unit < 0 {
+		t.Fatalf("expected epair driver got %+v", info)
+	}
+
+	if _, err := LinkDriverInfo(&Device{LinkAttrs: LinkAttrs{Name: "nonexistent0"}}); err == nil {
+		t.Fatal("driver info of nonexistent link")
+	}
 }
 
+func TestNamingRuleMatch(t *testing.T) {
+	hwaddr, _ := net.ParseMAC("00:1b:21:00:00:01")
+	id := &linkIdentity{
+		HardwareAddr: hwaddr,
+		Driver:       &DriverInfo{Driver: "igb", Unit: 1, BusInfo: "pci0:3:0:1"},
+		Description:  "uplink to isp",
+	}
+	for _, tt := range []struct {
+		rule NamingRule
+		want bool
+	}{
+		{NamingRule{}, false},
+		{NamingRule{HardwareAddr: hwaddr}, true},
+		{NamingRule{Driver: "igb"}, true},
+		{NamingRule{Driver: "igb1"}, true},
+		{NamingRule{Driver: "igb0"}, false},
+		{NamingRule{Location: "pci0:3:0:*"}, true},
+		{NamingRule{Description: "uplink*"}, true},
+		{NamingRule{Driver: "igb", Description: "lan*"}, false},
+	} {
+		if got := tt.rule.match(id); got != tt.want {
+			t.Errorf("%+v: match = %v, want %v", tt.rule, got, tt.want)
+		}
+	}
+
+	// the driver is only looked up for rules that match on it
+	lookups := 0
+	lazy := &linkIdentity{
+		Description: "uplink to isp",
+		lookupDriver: func() (*DriverInfo, error) {
+			lookups++
+			return &DriverInfo{Driver: "igb", Unit: 1}, nil
+		},
+	}
+	if !(&NamingRule{Description: "uplink*"}).match(lazy) || lookups != 0 {
+		t.Fatalf("driver looked up %d times for a description rule", lookups)
+	}
+	if (&NamingRule{Driver: "igb", Description: "lan*"}).match(lazy) || lookups != 0 {
+		t.Fatalf("driver looked up %d times for a rule not matching the description", lookups)
+	}
+	for i := 0; i < 2; i++ {
+		if !(&NamingRule{Driver: "igb1"}).match(lazy) {
+			t.Fatal("driver rule did not match")
+		}
+	}
+	if lookups != 1 {
+		t.Fatalf("driver looked up %d times, want 1", lookups)
+	}
+}
+
+func TestApplyNamingRules(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	link := &Veth{LinkAttrs: LinkAttrs{Name: "foo", Alias: "uplink"}, PeerName: "bar"}
+	if err := LinkAdd(link); err != nil {
+		t.Fatal(err)
+	}
+
+	// the name is taken, the MTU is set back
+	rules := []NamingRule{{Description: "uplink", Name: "bar", MTU: 1400}}
+	if err := ApplyNamingRules(rules); !errors.Is(err, unix.EEXIST) {
+		t.Fatalf("rule renaming to a taken name returned %v", err)
+	}
+	foo, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if foo.Attrs().MTU == 1400 {
+		t.Fatal("MTU not rolled back")
+	}
+
+	rules = []NamingRule{{Description: "uplink", Name: "wan0", MTU: 1400, Groups: []string{"wan"}}}
+	if err := ApplyNamingRules(rules); err != nil {
+		t.Fatal(err)
+	}
+	wan, err := LinkByName("wan0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if wan.Attrs().MTU != 1400 {
+		t.Fatalf("unexpected mtu %d", wan.Attrs().MTU)
+	}
+	// cloned links are in the group of their cloner as well
+	if !strings.Contains(" "+strings.Join(wan.Attrs().Groups, " ")+" ", " wan ") {
+		t.Fatalf("unexpected groups %v", wan.Attrs().Groups)
+	}
+}
+
+func TestLinkCache(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	// closing the cache is not reported as an error
+	var cbErr error
+	closing, err := NewLinkCache(LinkSubscribeOptions{
+		ErrorCallback: func(err error) { cbErr = err },
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	closing.Close()
+	if err := closing.Err(); err != nil || cbErr != nil {
+		t.Fatalf("error after Close: %v, %v", err, cbErr)
+	}
+
+	cache, err := NewLinkCache(LinkSubscribeOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cache.Close()
+
+	lo, err := LinkByName("lo0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index, ok := cache.IndexByName("lo0"); !ok || index != lo.Attrs().Index {
+		t.Fatalf("existing link lo0 not cached: %d %v", index, ok)
+	}
+
+	updates := make(chan LinkUpdate, 16)
+	remove := cache.OnChange(func(update LinkUpdate) {
+		select {
+		case updates <- update:
+		default:
+		}
+	})
+	defer remove()
+
+	version := cache.Version()
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo"}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+	timeout := time.After(time.Minute)
+	for {
+		if _, ok := cache.LinkByName("foo"); ok {
+			break
+		}
+		select {
+		case <-updates:
+		case <-timeout:
+			t.Fatal("link foo not cached")
+		}
+	}
+	if cache.Version() <= version {
+		t.Fatal("version not increased")
+	}
+
+	foo, _ := cache.LinkByName("foo")
+	index := foo.Attrs().Index
+	if name, ok := cache.NameByIndex(index); !ok || name != "foo" {
+		t.Fatalf("unexpected name %q", name)
+	}
+	if cache.LinkVersion(index) == 0 {
+		t.Fatal("link version not set")
+	}
+
+	SetLinkCache(cache)
+	defer SetLinkCache(nil)
+	attrs := &LinkAttrs{Name: "foo"}
+	ensureIndex(attrs)
+	if attrs.Index != index {
+		t.Fatalf("ensureIndex = %d, want %d", attrs.Index, index)
+	}
+
+	// names by index come from the kernel, not the cache lagging behind
+	if err := LinkSetName(&Device{LinkAttrs{Name: "foo"}}, "baz"); err != nil {
+		t.Fatal(err)
+	}
+	byIndex := &Device{LinkAttrs{Index: index}}
+	if name, err := pkgHandle.linkName(byIndex); err != nil || name != "baz" {
+		t.Fatalf("linkName after rename = %q, %v", name, err)
+	}
+	attrs = &LinkAttrs{Name: "baz"}
+	ensureIndex(attrs)
+	if attrs.Index != index {
+		t.Fatalf("ensureIndex after rename = %d, want %d", attrs.Index, index)
+	}
+
+	if err := LinkDel(byIndex); err != nil {
+		t.Fatal(err)
+	}
+	for {
+		if _, ok := cache.LinkByIndex(index); !ok {
+			break
+		}
+		select {
+		case <-updates:
+		case <-timeout:
+			t.Fatal("link foo not removed from the cache")
+		}
+	}
+}
+
+func TestLinkListRIB(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	if err := LinkAdd(&Veth{LinkAttrs: LinkAttrs{Name: "foo", MTU: 1400}, PeerName: "bar"}); err != nil {
+		t.Fatal(err)
+	}
+
+	links, err := LinkList()
+	if err != nil {
+		t.Fatal(err)
+	}
+	ribLinks, err := linkListRIB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ribLinks) != len(links) {
+		t.Fatalf("%d links from NET_RT_IFLIST, %d from netlink", len(ribLinks), len(links))
+	}
+	for i, link := range links {
+		want, got := link.Attrs(), ribLinks[i].Attrs()
+		if got.Index != want.Index || got.Name != want.Name || got.MTU != want.MTU ||
+			got.Flags != want.Flags || got.EncapType != want.EncapType ||
+			!bytes.Equal(got.HardwareAddr, want.HardwareAddr) {
+			t.Fatalf("link from NET_RT_IFLIST %+v, from netlink %+v", got, want)
+		}
+		if link.Type() != ribLinks[i].Type() {
+			t.Fatalf("%s: type %s from NET_RT_IFLIST, %s from netlink", want.Name, ribLinks[i].Type(), link.Type())
+		}
+	}
+
+	foo, err := linkByNameRIB("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkgHandle.fillLinkAttrs(foo)
+	if veth, ok := foo.(*Veth); !ok || veth.PeerName != "bar" {
+		t.Fatalf("unexpected link %+v", foo)
+	}
+	if _, err := linkByNameRIB("baz"); err == nil {
+		t.Fatal("found link baz")
+	} else if _, ok := err.(LinkNotFoundError); !ok {
+		t.Fatalf("unexpected error %v", err)
+	}
+}
+
+func TestLinkUpdateFromRouteMessage(t *testing.T) {
+	// struct if_announcemsghdr of a departure
+	b := make([]byte, 24)
+	native.PutUint16(b[0:2], uint16(len(b)))
+	b[2] = unix.RTM_VERSION
+	b[3] = unix.RTM_IFANNOUNCE
+	native.PutUint16(b[4:6], 7)
+	copy(b[6:22], "foo")
+	native.PutUint16(b[22:24], unix.IFAN_DEPARTURE)
+
+	update, err := linkUpdateFromRouteMessage(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if update == nil || update.Header.Type != nlunix.RTM_DELLINK ||
+		update.Index != 7 || update.Attrs().Name != "foo" {
+		t.Fatalf("unexpected update %+v", update)
+	}
+}
@@ -129,6 +129,9 @@ func neighAdd(neigh *Neigh, mode int) error {
 // NeighAppend will append an entry to FDB
 // Equivalent to: `bridge fdb append...`
 func (h *Handle) neighAdd(neigh *Neigh, mode int) error {
+	if neigh.Flags&NTF_MASTER != 0 {
+		return h.bridgeFdbModify(neigh, true)
+	}
 	req := h.newNetlinkRequest(nlunix.RTM_NEWNEIGH, mode|nlunix.NLM_F_ACK)
 	return neighHandle(neigh, req)
 }
@@ -142,6 +145,9 @@ func NeighDel(neigh *Neigh) error {
 // NeighDel will delete an IP address from a link device.
 // Equivalent to: `ip addr del $addr dev $link`
 func (h *Handle) NeighDel(neigh *Neigh) error {
+	if neigh.Flags&NTF_MASTER != 0 {
+		return h.bridgeFdbModify(neigh, false)
+	}
 	req := h.newNetlinkRequest(nlunix.RTM_DELNEIGH, nlunix.NLM_F_ACK)
 	return neighHandle(neigh, req)
 }
@@ -246,6 +252,9 @@ func NeighListExecute(msg Ndmsg) ([]Neigh, error) {
 
 // NeighListExecute returns a list of neighbour entries filtered by link, ip family, flag and state.
 func (h *Handle) NeighListExecute(msg Ndmsg) ([]Neigh, error) {
+	if msg.Family == nlunix.AF_BRIDGE {
+		return h.bridgeFdbListExecute(msg)
+	}
 	req := h.newNetlinkRequest(nlunix.RTM_GETNEIGH, nlunix.NLM_F_DUMP)
 	req.AddData(&msg)
 
@@ -285,6 +294,101 @@ func (h *Handle) NeighListExecute(msg Ndmsg) ([]Neigh, error) {
 	return res, nil
 }
 
+// bridgeFdbListExecute returns the address caches of all bridges,
+// filtered like NeighListExecute. msg.Index matches either the member
+// port or the bridge.
+func (h *Handle) bridgeFdbListExecute(msg Ndmsg) ([]Neigh, error) {
+	fd, err := getSocketUDP()
+	if err != nil {
+		return nil, fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	bridges, err := groupMembers(fd, "bridge")
+	if err != nil {
+		return nil, err
+	}
+
+	var res []Neigh
+	for _, bridge := range bridges {
+		index, err := linkIndexByName(fd, bridge)
+		if err != nil {
+			return nil, err
+		}
+		neighs, err := bridgeFdbList(fd, bridge, index)
+		if err != nil {
+			return nil, err
+		}
+		for _, neigh := range neighs {
+			if msg.Index != 0 && uint32(neigh.LinkIndex) != msg.Index && uint32(index) != msg.Index {
+				continue
+			}
+			if msg.State != 0 && uint16(neigh.State) != msg.State {
+				continue
+			}
+			if msg.Flags != 0 && uint8(neigh.Flags) != msg.Flags {
+				continue
+			}
+			res = append(res, neigh)
+		}
+	}
+	return res, nil
+}
+
+// bridgeFdbModify adds or deletes the NTF_MASTER entry neigh in the
+// address cache of the bridge LinkIndex is a member of, or of
+// MasterIndex if set. Entries are added static unless State is
+// NUD_REACHABLE, NTF_STICKY adds a sticky entry.
+func (h *Handle) bridgeFdbModify(neigh *Neigh, add bool) error {
+	if neigh.HardwareAddr == nil {
+		return fmt.Errorf("bridge fdb entry requires HardwareAddr")
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	var port string
+	masterIndex := neigh.MasterIndex
+	if neigh.LinkIndex != 0 {
+		link, err := h.LinkByIndex(neigh.LinkIndex)
+		if err != nil {
+			return err
+		}
+		port = link.Attrs().Name
+		if masterIndex == 0 {
+			masterIndex = link.Attrs().MasterIndex
+			if _, ok := link.(*Bridge); ok {
+				masterIndex = neigh.LinkIndex
+			}
+		}
+	}
+	if masterIndex == 0 {
+		return fmt.Errorf("bridge fdb entry requires a bridge or member in LinkIndex or MasterIndex")
+	}
+	master, err := h.LinkByIndex(masterIndex)
+	if err != nil {
+		return err
+	}
+	bridge := master.Attrs().Name
+
+	if !add {
+		return bridgeFdbDel(fd, bridge, neigh.HardwareAddr, neigh.Vlan)
+	}
+	if port == "" || port == bridge {
+		return fmt.Errorf("bridge fdb entry requires a member port in LinkIndex")
+	}
+	flags := uint8(IFBAF_STATIC)
+	if neigh.Flags&NTF_STICKY != 0 {
+		flags = IFBAF_STICKY
+	} else if neigh.State == NUD_REACHABLE {
+		flags = IFBAF_DYNAMIC
+	}
+	return bridgeFdbAdd(fd, bridge, port, neigh.HardwareAddr, neigh.Vlan, flags)
+}
+
 func NeighDeserialize(m []byte) (*Neigh, error) {
 	msg := deserializeNdmsg(m)
 
@@ -314,7 +418,7 @@ func NeighDeserialize(m []byte) (*Neigh, error) {
 				neigh.LLIPAddr = net.IP(attr.Value)
 			} else if attrLen == 16 {
 				// Can be IPv6 or FireWire HWAddr
-				link, err := LinkByIndex(neigh.LinkIndex)
+				link, err := pkgHandle.cachedLinkByIndex(neigh.LinkIndex)
 				if err == nil && link.Attrs().EncapType == "tunnel6" {
 					neigh.IP = net.IP(attr.Value)
 				} else {
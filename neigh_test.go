@@ -533,3 +533,86 @@ func TestNeighListExecuteStateFilter(t *testing.T) {
 		}
 	}
 }
+
+func dumpContainsFdb(dump []Neigh, mac net.HardwareAddr, port, state int) bool {
+	for _, n := range dump {
+		if n.HardwareAddr.String() == mac.String() && n.LinkIndex == port && n.State == state &&
+			n.Family == nlunix.AF_BRIDGE && n.Flags&NTF_MASTER != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func TestNeighAddDelBridgeFdb(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	bridge := &Bridge{LinkAttrs: LinkAttrs{Name: "foo"}}
+	if err := LinkAdd(bridge); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(bridge)
+	veth := &Veth{LinkAttrs: LinkAttrs{Name: "bar"}, PeerName: "baz"}
+	if err := LinkAdd(veth); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(veth)
+	if err := LinkSetMaster(veth, bridge); err != nil {
+		t.Fatal(err)
+	}
+	br, err := LinkByName("foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, err := LinkByName("bar")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	static := parseMAC("aa:bb:cc:dd:00:01")
+	sticky := parseMAC("aa:bb:cc:dd:00:02")
+	for _, neigh := range []*Neigh{
+		{LinkIndex: port.Attrs().Index, Flags: NTF_MASTER, HardwareAddr: static},
+		{LinkIndex: port.Attrs().Index, Flags: NTF_MASTER | NTF_STICKY, HardwareAddr: sticky},
+	} {
+		if err := NeighAdd(neigh); err != nil {
+			t.Fatalf("Failed to NeighAdd: %v", err)
+		}
+	}
+
+	for _, index := range []int{br.Attrs().Index, port.Attrs().Index} {
+		dump, err := NeighList(index, nlunix.AF_BRIDGE)
+		if err != nil {
+			t.Fatalf("Failed to NeighList: %v", err)
+		}
+		if !dumpContainsFdb(dump, static, port.Attrs().Index, NUD_NOARP) {
+			t.Errorf("Dump does not contain static entry %s: %v", static, dump)
+		}
+		if !dumpContainsFdb(dump, sticky, port.Attrs().Index, NUD_NOARP) {
+			t.Errorf("Dump does not contain sticky entry %s: %v", sticky, dump)
+		}
+	}
+
+	err = NeighDel(&Neigh{MasterIndex: br.Attrs().Index, Flags: NTF_MASTER, HardwareAddr: static})
+	if err != nil {
+		t.Fatalf("Failed to NeighDel: %v", err)
+	}
+	dump, err := NeighList(br.Attrs().Index, nlunix.AF_BRIDGE)
+	if err != nil {
+		t.Fatalf("Failed to NeighList: %v", err)
+	}
+	if dumpContainsFdb(dump, static, port.Attrs().Index, NUD_NOARP) {
+		t.Errorf("Dump contains deleted entry %s", static)
+	}
+
+	if err := BridgeFdbFlush(br, true); err != nil {
+		t.Fatal(err)
+	}
+	if dump, err = NeighList(br.Attrs().Index, nlunix.AF_BRIDGE); err != nil {
+		t.Fatalf("Failed to NeighList: %v", err)
+	}
+	if len(dump) != 0 {
+		t.Errorf("Dump not empty after flush: %v", dump)
+	}
+}
@@ -573,6 +573,18 @@ func GetNetlinkSocketAt(newNs, curNs vnet.VjHandle, protocol int) (*NetlinkSocke
 	return getNetlinkSocket(protocol)
 }
 
+// ExecuteAt runs f in the network namespace newNs and positions the thread
+// back into curNs, or the current network namespace if curNs is closed,
+// when done. If newNs is closed, f runs in the current network namespace.
+func ExecuteAt(newNs, curNs vnet.VjHandle, f func() error) error {
+	c, err := executeInNetns(newNs, curNs)
+	if err != nil {
+		return err
+	}
+	defer c()
+	return f()
+}
+
 // executeInNetns sets execution of the code following this call to the
 // network namespace newNs, then moves the thread back to curNs if open,
 // otherwise to the current netns at the time the function was invoked
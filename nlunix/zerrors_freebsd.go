@@ -66,6 +66,7 @@ const (
 	RTAX_FASTOPEN_NO_COOKIE   = 0x11   // not supported
 	RTA_ALIGNTO               = 0x4    // sizeof(uint32_t)
 	RTM_DELLINK               = 0x11
+	RTM_DELADDR               = 0x15
 	RTM_DELROUTE              = 0x19
 	RTM_DELRULE               = 0x21   // not supported
 	RTM_DELNEIGH              = 0x1d
@@ -0,0 +1,597 @@
+// Package nv implements the binary encoding of FreeBSD name/value lists
+// (nvlists, see nv(9)). The kernel exchanges nvlists with userland through
+// a number of ioctls, e.g. SIOCSWG/SIOCGWG for if_wg(4) and SIOCGIFCAPNV
+// for interface capabilities.
+//
+// A List is an ordered set of named values. Supported value types are
+// null, bool, number (uint64), string, binary ([]byte), nested lists and
+// arrays of bool, number, string and nested lists. Descriptors are not
+// supported since they cannot be passed through an ioctl.
+package nv
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// Value types as defined in sys/sys/nv.h.
+const (
+	TypeNull            = 1
+	TypeBool            = 2
+	TypeNumber          = 3
+	TypeString          = 4
+	TypeNvlist          = 5
+	TypeDescriptor      = 6
+	TypeBinary          = 7
+	TypeBoolArray       = 8
+	TypeNumberArray     = 9
+	TypeStringArray     = 10
+	TypeNvlistArray     = 11
+	TypeDescriptorArray = 12
+
+	// private to the packed format, they terminate nested lists
+	typeNvlistArrayNext = 254
+	typeNvlistUp        = 255
+)
+
+const (
+	headerMagic   = 'l'
+	headerVersion = 0
+
+	flagBigEndian = 0x80
+	flagAllMask   = 0x83
+
+	sizeofListHeader = 19 // magic, version, flags, descriptors, size
+	sizeofPairHeader = 19 // type, namesize, datasize, nitems
+
+	// NameMax is the maximum length of a pair name, including the
+	// terminating NUL byte.
+	NameMax = 2048
+)
+
+// ErrInvalid is returned when a packed nvlist is malformed.
+var ErrInvalid = errors.New("nv: invalid packed nvlist")
+
+// Pair is a single named value of a List.
+type Pair struct {
+	Name  string
+	Value interface{}
+}
+
+// Type returns the nvlist type of the pair value.
+func (p Pair) Type() int {
+	switch p.Value.(type) {
+	case nil:
+		return TypeNull
+	case bool:
+		return TypeBool
+	case uint64:
+		return TypeNumber
+	case string:
+		return TypeString
+	case *List:
+		return TypeNvlist
+	case []byte:
+		return TypeBinary
+	case []bool:
+		return TypeBoolArray
+	case []uint64:
+		return TypeNumberArray
+	case []string:
+		return TypeStringArray
+	case []*List:
+		return TypeNvlistArray
+	}
+	return 0
+}
+
+// List is an ordered name/value list. The zero value is an empty list
+// ready to use.
+type List struct {
+	pairs []Pair
+}
+
+// NewList returns an empty list.
+func NewList() *List {
+	return &List{}
+}
+
+// Pairs returns the pairs of the list in insertion order.
+func (l *List) Pairs() []Pair {
+	return l.pairs
+}
+
+// Len returns the number of pairs in the list.
+func (l *List) Len() int {
+	return len(l.pairs)
+}
+
+func (l *List) add(name string, value interface{}) {
+	l.pairs = append(l.pairs, Pair{Name: name, Value: value})
+}
+
+// AddNull adds a pair without a value.
+func (l *List) AddNull(name string) {
+	l.add(name, nil)
+}
+
+// AddBool adds a bool pair.
+func (l *List) AddBool(name string, v bool) {
+	l.add(name, v)
+}
+
+// AddNumber adds a number pair.
+func (l *List) AddNumber(name string, v uint64) {
+	l.add(name, v)
+}
+
+// AddString adds a string pair.
+func (l *List) AddString(name string, v string) {
+	l.add(name, v)
+}
+
+// AddBinary adds a binary pair. The kernel rejects empty binary values.
+func (l *List) AddBinary(name string, v []byte) {
+	l.add(name, v)
+}
+
+// AddList adds a nested list.
+func (l *List) AddList(name string, v *List) {
+	l.add(name, v)
+}
+
+// AddBoolArray adds an array of bools.
+func (l *List) AddBoolArray(name string, v []bool) {
+	l.add(name, v)
+}
+
+// AddNumberArray adds an array of numbers.
+func (l *List) AddNumberArray(name string, v []uint64) {
+	l.add(name, v)
+}
+
+// AddStringArray adds an array of strings.
+func (l *List) AddStringArray(name string, v []string) {
+	l.add(name, v)
+}
+
+// AddListArray adds an array of nested lists.
+func (l *List) AddListArray(name string, v []*List) {
+	l.add(name, v)
+}
+
+func (l *List) get(name string) (interface{}, bool) {
+	for _, p := range l.pairs {
+		if p.Name == name {
+			return p.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Exists reports whether a pair with the given name is in the list.
+func (l *List) Exists(name string) bool {
+	_, ok := l.get(name)
+	return ok
+}
+
+// GetBool returns the value of the named bool pair.
+func (l *List) GetBool(name string) (bool, bool) {
+	v, _ := l.get(name)
+	b, ok := v.(bool)
+	return b, ok
+}
+
+// GetNumber returns the value of the named number pair.
+func (l *List) GetNumber(name string) (uint64, bool) {
+	v, _ := l.get(name)
+	n, ok := v.(uint64)
+	return n, ok
+}
+
+// GetString returns the value of the named string pair.
+func (l *List) GetString(name string) (string, bool) {
+	v, _ := l.get(name)
+	s, ok := v.(string)
+	return s, ok
+}
+
+// GetBinary returns the value of the named binary pair.
+func (l *List) GetBinary(name string) ([]byte, bool) {
+	v, _ := l.get(name)
+	b, ok := v.([]byte)
+	return b, ok
+}
+
+// GetList returns the named nested list.
+func (l *List) GetList(name string) (*List, bool) {
+	v, _ := l.get(name)
+	nl, ok := v.(*List)
+	return nl, ok
+}
+
+// GetBoolArray returns the value of the named bool array.
+func (l *List) GetBoolArray(name string) ([]bool, bool) {
+	v, _ := l.get(name)
+	a, ok := v.([]bool)
+	return a, ok
+}
+
+// GetNumberArray returns the value of the named number array.
+func (l *List) GetNumberArray(name string) ([]uint64, bool) {
+	v, _ := l.get(name)
+	a, ok := v.([]uint64)
+	return a, ok
+}
+
+// GetStringArray returns the value of the named string array.
+func (l *List) GetStringArray(name string) ([]string, bool) {
+	v, _ := l.get(name)
+	a, ok := v.([]string)
+	return a, ok
+}
+
+// GetListArray returns the named array of nested lists.
+func (l *List) GetListArray(name string) ([]*List, bool) {
+	v, _ := l.get(name)
+	a, ok := v.([]*List)
+	return a, ok
+}
+
+// size returns the packed size of the list, the same way nvlist_size()
+// does: nested lists include their header and pairs but not the pair
+// terminating them.
+func (l *List) size() int {
+	size := sizeofListHeader
+	for _, p := range l.pairs {
+		size += sizeofPairHeader + len(p.Name) + 1
+		switch v := p.Value.(type) {
+		case *List:
+			size += v.size() + sizeofPairHeader + 1
+		case []*List:
+			for _, e := range v {
+				size += e.size() + sizeofPairHeader + 1
+			}
+		default:
+			size += dataSize(p.Value)
+		}
+	}
+	return size
+}
+
+func dataSize(value interface{}) int {
+	switch v := value.(type) {
+	case bool:
+		return 1
+	case uint64:
+		return 8
+	case string:
+		return len(v) + 1
+	case []byte:
+		return len(v)
+	case []bool:
+		return len(v)
+	case []uint64:
+		return 8 * len(v)
+	case []string:
+		n := 0
+		for _, s := range v {
+			n += len(s) + 1
+		}
+		return n
+	case []*List:
+		// libnv stores the size of the pointer array here
+		return 8 * len(v)
+	}
+	return 0
+}
+
+func itemCount(value interface{}) int {
+	switch v := value.(type) {
+	case []bool:
+		return len(v)
+	case []uint64:
+		return len(v)
+	case []string:
+		return len(v)
+	case []*List:
+		return len(v)
+	}
+	return 0
+}
+
+type encoder struct {
+	buf   []byte
+	total int
+}
+
+func (e *encoder) listHeader() {
+	var h [sizeofListHeader]byte
+	h[0] = headerMagic
+	h[1] = headerVersion
+	binary.LittleEndian.PutUint64(h[11:], uint64(e.total-len(e.buf)-sizeofListHeader))
+	e.buf = append(e.buf, h[:]...)
+}
+
+func (e *encoder) pairHeader(typ int, name string, datasize, nitems int) {
+	var h [sizeofPairHeader]byte
+	h[0] = byte(typ)
+	binary.LittleEndian.PutUint16(h[1:], uint16(len(name)+1))
+	binary.LittleEndian.PutUint64(h[3:], uint64(datasize))
+	binary.LittleEndian.PutUint64(h[11:], uint64(nitems))
+	e.buf = append(e.buf, h[:]...)
+	e.buf = append(e.buf, name...)
+	e.buf = append(e.buf, 0)
+}
+
+func (e *encoder) pairs(l *List) error {
+	for _, p := range l.pairs {
+		if len(p.Name)+1 > NameMax {
+			return fmt.Errorf("nv: name of %q too long", p.Name)
+		}
+		typ := p.Type()
+		if typ == 0 {
+			return fmt.Errorf("nv: unsupported type %T for %q", p.Value, p.Name)
+		}
+		switch v := p.Value.(type) {
+		case *List:
+			e.pairHeader(typ, p.Name, v.size(), 0)
+			e.listHeader()
+			if err := e.pairs(v); err != nil {
+				return err
+			}
+			e.pairHeader(typeNvlistUp, "", 0, 0)
+			continue
+		case []*List:
+			if len(v) == 0 {
+				return fmt.Errorf("nv: empty nvlist array %q", p.Name)
+			}
+			e.pairHeader(typ, p.Name, dataSize(v), len(v))
+			for _, el := range v {
+				e.listHeader()
+				if err := e.pairs(el); err != nil {
+					return err
+				}
+				e.pairHeader(typeNvlistArrayNext, "", 0, 0)
+			}
+			continue
+		}
+
+		e.pairHeader(typ, p.Name, dataSize(p.Value), itemCount(p.Value))
+		switch v := p.Value.(type) {
+		case bool:
+			e.buf = append(e.buf, boolByte(v))
+		case uint64:
+			e.buf = binary.LittleEndian.AppendUint64(e.buf, v)
+		case string:
+			e.buf = append(e.buf, v...)
+			e.buf = append(e.buf, 0)
+		case []byte:
+			e.buf = append(e.buf, v...)
+		case []bool:
+			for _, b := range v {
+				e.buf = append(e.buf, boolByte(b))
+			}
+		case []uint64:
+			for _, n := range v {
+				e.buf = binary.LittleEndian.AppendUint64(e.buf, n)
+			}
+		case []string:
+			for _, s := range v {
+				e.buf = append(e.buf, s...)
+				e.buf = append(e.buf, 0)
+			}
+		}
+	}
+	return nil
+}
+
+func boolByte(b bool) byte {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Pack serializes the list in the format expected by nvlist_unpack().
+// Numbers are always written in little endian byte order, the kernel
+// converts them according to the header flags.
+func (l *List) Pack() ([]byte, error) {
+	total := l.size()
+	e := &encoder{buf: make([]byte, 0, total), total: total}
+	e.listHeader()
+	if err := e.pairs(l); err != nil {
+		return nil, err
+	}
+	return e.buf, nil
+}
+
+type decoder struct {
+	buf   []byte
+	order binary.ByteOrder
+}
+
+func (d *decoder) listHeader() error {
+	if len(d.buf) < sizeofListHeader {
+		return ErrInvalid
+	}
+	if d.buf[0] != headerMagic || d.buf[1] != headerVersion {
+		return ErrInvalid
+	}
+	if d.buf[2]&^flagAllMask != 0 {
+		return ErrInvalid
+	}
+	if d.buf[2]&flagBigEndian != 0 {
+		d.order = binary.BigEndian
+	} else {
+		d.order = binary.LittleEndian
+	}
+	if d.order.Uint64(d.buf[3:]) != 0 {
+		return fmt.Errorf("nv: descriptors are not supported")
+	}
+	d.buf = d.buf[sizeofListHeader:]
+	return nil
+}
+
+func (d *decoder) pairHeader() (typ int, name string, datasize, nitems int, err error) {
+	if len(d.buf) < sizeofPairHeader {
+		return 0, "", 0, 0, ErrInvalid
+	}
+	typ = int(d.buf[0])
+	namesize := int(d.order.Uint16(d.buf[1:]))
+	ds := d.order.Uint64(d.buf[3:])
+	ni := d.order.Uint64(d.buf[11:])
+	d.buf = d.buf[sizeofPairHeader:]
+
+	if namesize < 1 || namesize > NameMax || namesize > len(d.buf) {
+		return 0, "", 0, 0, ErrInvalid
+	}
+	if d.buf[namesize-1] != 0 {
+		return 0, "", 0, 0, ErrInvalid
+	}
+	name = string(d.buf[:namesize-1])
+	d.buf = d.buf[namesize:]
+	if ds > uint64(len(d.buf)) || ni > uint64(len(d.buf)) {
+		return 0, "", 0, 0, ErrInvalid
+	}
+	return typ, name, int(ds), int(ni), nil
+}
+
+func (d *decoder) data(n int) []byte {
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+// pairs decodes pairs into l until the terminator pair type is found.
+// The top level list is terminated by the end of the buffer (term 0).
+func (d *decoder) pairs(l *List, term int) error {
+	for {
+		if len(d.buf) == 0 {
+			if term == 0 {
+				return nil
+			}
+			return ErrInvalid
+		}
+		typ, name, datasize, nitems, err := d.pairHeader()
+		if err != nil {
+			return err
+		}
+		if typ == typeNvlistUp || typ == typeNvlistArrayNext {
+			if typ != term {
+				return ErrInvalid
+			}
+			return nil
+		}
+
+		var value interface{}
+		switch typ {
+		case TypeNull:
+			if datasize != 0 {
+				return ErrInvalid
+			}
+		case TypeBool:
+			if datasize != 1 {
+				return ErrInvalid
+			}
+			value = d.data(1)[0] != 0
+		case TypeNumber:
+			if datasize != 8 {
+				return ErrInvalid
+			}
+			value = d.order.Uint64(d.data(8))
+		case TypeString:
+			if datasize == 0 {
+				return ErrInvalid
+			}
+			b := d.data(datasize)
+			if b[datasize-1] != 0 {
+				return ErrInvalid
+			}
+			value = string(b[:datasize-1])
+		case TypeBinary:
+			if datasize == 0 {
+				return ErrInvalid
+			}
+			value = append([]byte(nil), d.data(datasize)...)
+		case TypeBoolArray:
+			if nitems == 0 || datasize != nitems {
+				return ErrInvalid
+			}
+			a := make([]bool, nitems)
+			for i, b := range d.data(datasize) {
+				a[i] = b != 0
+			}
+			value = a
+		case TypeNumberArray:
+			if nitems == 0 || datasize != 8*nitems {
+				return ErrInvalid
+			}
+			b := d.data(datasize)
+			a := make([]uint64, nitems)
+			for i := range a {
+				a[i] = d.order.Uint64(b[8*i:])
+			}
+			value = a
+		case TypeStringArray:
+			if nitems == 0 || datasize == 0 {
+				return ErrInvalid
+			}
+			b := d.data(datasize)
+			a := make([]string, 0, nitems)
+			for start, i := 0, 0; i < len(b); i++ {
+				if b[i] == 0 {
+					a = append(a, string(b[start:i]))
+					start = i + 1
+				}
+			}
+			if len(a) != nitems || b[len(b)-1] != 0 {
+				return ErrInvalid
+			}
+			value = a
+		case TypeNvlist:
+			child := &List{}
+			if err := d.listHeader(); err != nil {
+				return err
+			}
+			if err := d.pairs(child, typeNvlistUp); err != nil {
+				return err
+			}
+			value = child
+		case TypeNvlistArray:
+			if nitems == 0 {
+				return ErrInvalid
+			}
+			a := make([]*List, nitems)
+			for i := range a {
+				a[i] = &List{}
+				if err := d.listHeader(); err != nil {
+					return err
+				}
+				if err := d.pairs(a[i], typeNvlistArrayNext); err != nil {
+					return err
+				}
+			}
+			value = a
+		case TypeDescriptor, TypeDescriptorArray:
+			return fmt.Errorf("nv: descriptors are not supported")
+		default:
+			return ErrInvalid
+		}
+		l.add(name, value)
+	}
+}
+
+// Unpack parses a packed nvlist as produced by nvlist_pack().
+func Unpack(b []byte) (*List, error) {
+	d := &decoder{buf: b}
+	if err := d.listHeader(); err != nil {
+		return nil, err
+	}
+	l := &List{}
+	if err := d.pairs(l, 0); err != nil {
+		return nil, err
+	}
+	return l, nil
+}
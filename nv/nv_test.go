@@ -0,0 +1,165 @@
+package nv
+
+import (
+	"bytes"
+	"encoding/binary"
+	"reflect"
+	"testing"
+)
+
+func pairHeader(order binary.AppendByteOrder, typ byte, name string, datasize, nitems uint64) []byte {
+	b := []byte{typ}
+	b = order.AppendUint16(b, uint16(len(name)+1))
+	b = order.AppendUint64(b, datasize)
+	b = order.AppendUint64(b, nitems)
+	b = append(b, name...)
+	return append(b, 0)
+}
+
+func listHeader(order binary.AppendByteOrder, flags byte, size uint64) []byte {
+	b := []byte{'l', 0, flags}
+	b = order.AppendUint64(b, 0)
+	return order.AppendUint64(b, size)
+}
+
+func TestPackNumber(t *testing.T) {
+	l := NewList()
+	l.AddNumber("listen-port", 51820)
+
+	body := pairHeader(binary.LittleEndian, TypeNumber, "listen-port", 8, 0)
+	body = binary.LittleEndian.AppendUint64(body, 51820)
+	expected := append(listHeader(binary.LittleEndian, 0, uint64(len(body))), body...)
+
+	b, err := l.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, expected) {
+		t.Fatalf("Pack() = %x, expected %x", b, expected)
+	}
+}
+
+func TestPackNested(t *testing.T) {
+	child := NewList()
+	child.AddBool("remove", true)
+	l := NewList()
+	l.AddList("peer", child)
+
+	le := binary.LittleEndian
+	childBody := pairHeader(le, TypeBool, "remove", 1, 0)
+	childBody = append(childBody, 1)
+	up := pairHeader(le, typeNvlistUp, "", 0, 0)
+	childSize := uint64(sizeofListHeader + len(childBody))
+
+	pair := pairHeader(le, TypeNvlist, "peer", childSize, 0)
+	total := sizeofListHeader + len(pair) + int(childSize) + len(up)
+
+	var expected []byte
+	expected = append(expected, listHeader(le, 0, uint64(total-sizeofListHeader))...)
+	expected = append(expected, pair...)
+	expected = append(expected, listHeader(le, 0, uint64(total-len(expected)-sizeofListHeader))...)
+	expected = append(expected, childBody...)
+	expected = append(expected, up...)
+
+	b, err := l.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(b, expected) {
+		t.Fatalf("Pack() = %x, expected %x", b, expected)
+	}
+}
+
+func TestPackUnpack(t *testing.T) {
+	aip4 := NewList()
+	aip4.AddBinary("ipv4", []byte{10, 0, 0, 0})
+	aip4.AddNumber("cidr", 8)
+	aip6 := NewList()
+	aip6.AddBinary("ipv6", bytes.Repeat([]byte{0xfd}, 16))
+	aip6.AddNumber("cidr", 64)
+
+	peer := NewList()
+	peer.AddBinary("public-key", bytes.Repeat([]byte{1}, 32))
+	peer.AddListArray("allowed-ips", []*List{aip4, aip6})
+	peer.AddBool("replace-allowedips", true)
+
+	empty := NewList()
+
+	l := NewList()
+	l.AddNull("null")
+	l.AddString("name", "wg0")
+	l.AddNumber("listen-port", 51820)
+	l.AddListArray("peers", []*List{peer, empty})
+	l.AddList("nested", empty)
+	l.AddBoolArray("bools", []bool{true, false, true})
+	l.AddNumberArray("numbers", []uint64{1, 2, 1 << 40})
+	l.AddStringArray("strings", []string{"a", "", "bc"})
+	l.AddBool("last", false)
+
+	b, err := l.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(b) != l.size() {
+		t.Fatalf("packed %d bytes, expected %d", len(b), l.size())
+	}
+
+	res, err := Unpack(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(l, res) {
+		t.Fatalf("Unpack() = %+v, expected %+v", res, l)
+	}
+
+	peers, ok := res.GetListArray("peers")
+	if !ok || len(peers) != 2 {
+		t.Fatalf("peers missing: %v", peers)
+	}
+	aips, ok := peers[0].GetListArray("allowed-ips")
+	if !ok || len(aips) != 2 {
+		t.Fatalf("allowed-ips missing: %v", aips)
+	}
+	if cidr, ok := aips[1].GetNumber("cidr"); !ok || cidr != 64 {
+		t.Fatalf("cidr is %d, expected 64", cidr)
+	}
+	if !res.Exists("null") || res.Exists("missing") {
+		t.Fatal("Exists() returned unexpected result")
+	}
+}
+
+func TestUnpackBigEndian(t *testing.T) {
+	be := binary.BigEndian
+	body := pairHeader(be, TypeNumber, "n", 8, 0)
+	body = be.AppendUint64(body, 0x0102030405060708)
+	b := append(listHeader(be, flagBigEndian, uint64(len(body))), body...)
+
+	l, err := Unpack(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n, ok := l.GetNumber("n"); !ok || n != 0x0102030405060708 {
+		t.Fatalf("number is %#x, expected %#x", n, 0x0102030405060708)
+	}
+}
+
+func TestUnpackInvalid(t *testing.T) {
+	l := NewList()
+	l.AddList("nested", NewList())
+	good, err := l.Pack()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, b := range map[string][]byte{
+		"empty":     {},
+		"magic":     append([]byte{'x'}, good[1:]...),
+		"truncated": good[:len(good)-1],
+		"unterminated": append(listHeader(binary.LittleEndian, 0, 0),
+			pairHeader(binary.LittleEndian, TypeNvlist, "n", sizeofListHeader, 0)...),
+	} {
+		if _, err := Unpack(b); err == nil {
+			t.Errorf("Unpack(%s) succeeded, expected error", name)
+		}
+	}
+}
@@ -1,6 +1,7 @@
 package netlink
 
 import (
+	"fmt"
 	"strings"
 )
 
@@ -16,6 +17,71 @@ type Protinfo struct {
 	ProxyArpWiFi  bool
 	Isolated      bool
 	NeighSuppress bool
+
+	// if_bridge member flags without a Linux equivalent
+	Stp    bool // participates in spanning tree
+	Edge   bool // spanning tree edge port
+	Sticky bool // learned addresses are static
+
+	State BridgePortState
+	Role  BridgePortRole
+}
+
+// BridgePortState is the spanning tree state of a bridge port.
+type BridgePortState uint8
+
+const (
+	BRIDGE_PORT_STATE_DISABLED BridgePortState = iota
+	BRIDGE_PORT_STATE_LISTENING
+	BRIDGE_PORT_STATE_LEARNING
+	BRIDGE_PORT_STATE_FORWARDING
+	BRIDGE_PORT_STATE_BLOCKING
+	BRIDGE_PORT_STATE_DISCARDING
+)
+
+func (s BridgePortState) String() string {
+	switch s {
+	case BRIDGE_PORT_STATE_DISABLED:
+		return "disabled"
+	case BRIDGE_PORT_STATE_LISTENING:
+		return "listening"
+	case BRIDGE_PORT_STATE_LEARNING:
+		return "learning"
+	case BRIDGE_PORT_STATE_FORWARDING:
+		return "forwarding"
+	case BRIDGE_PORT_STATE_BLOCKING:
+		return "blocking"
+	case BRIDGE_PORT_STATE_DISCARDING:
+		return "discarding"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(s))
+}
+
+// BridgePortRole is the spanning tree role of a bridge port.
+type BridgePortRole uint8
+
+const (
+	BRIDGE_PORT_ROLE_DISABLED BridgePortRole = iota
+	BRIDGE_PORT_ROLE_ROOT
+	BRIDGE_PORT_ROLE_DESIGNATED
+	BRIDGE_PORT_ROLE_ALTERNATE
+	BRIDGE_PORT_ROLE_BACKUP
+)
+
+func (r BridgePortRole) String() string {
+	switch r {
+	case BRIDGE_PORT_ROLE_DISABLED:
+		return "disabled"
+	case BRIDGE_PORT_ROLE_ROOT:
+		return "root"
+	case BRIDGE_PORT_ROLE_DESIGNATED:
+		return "designated"
+	case BRIDGE_PORT_ROLE_ALTERNATE:
+		return "alternate"
+	case BRIDGE_PORT_ROLE_BACKUP:
+		return "backup"
+	}
+	return fmt.Sprintf("unknown(%d)", uint8(r))
 }
 
 // String returns a list of enabled flags
@@ -55,6 +121,15 @@ func (prot *Protinfo) String() string {
 	if prot.NeighSuppress {
 		boolStrings = append(boolStrings, "NeighSuppress")
 	}
+	if prot.Stp {
+		boolStrings = append(boolStrings, "Stp")
+	}
+	if prot.Edge {
+		boolStrings = append(boolStrings, "Edge")
+	}
+	if prot.Sticky {
+		boolStrings = append(boolStrings, "Sticky")
+	}
 	return strings.Join(boolStrings, " ")
 }
 
@@ -1,8 +1,11 @@
 package netlink
 
 import (
+	"fmt"
+
 	"github.com/oss-fun/netlink/nlsyscall"
 	"github.com/oss-fun/netlink/nl"
+	"golang.org/x/sys/unix"
 )
 
 func parseProtinfo(infos []nlsyscall.NetlinkRouteAttr) (pi Protinfo) {
@@ -32,3 +35,42 @@ func parseProtinfo(infos []nlsyscall.NetlinkRouteAttr) (pi Protinfo) {
 	}
 	return
 }
+
+// parseBridgePortFlags translates the if_bridge member flags of req. Flood
+// maps to IFBIF_DISCOVER and Isolated to IFBIF_PRIVATE.
+func parseBridgePortFlags(req *ifBreq) (pi Protinfo) {
+	pi.Learning = req.IfsFlags&IFBIF_LEARNING != 0
+	pi.Flood = req.IfsFlags&IFBIF_DISCOVER != 0
+	pi.Isolated = req.IfsFlags&IFBIF_PRIVATE != 0
+	pi.Stp = req.IfsFlags&IFBIF_STP != 0
+	pi.Edge = req.IfsFlags&IFBIF_BSTP_EDGE != 0
+	pi.Sticky = req.IfsFlags&IFBIF_STICKY != 0
+	pi.State = BridgePortState(req.State)
+	pi.Role = BridgePortRole(req.Role)
+	return
+}
+
+// LinkGetProtinfo gets the bridge port flags of a bridge member.
+func LinkGetProtinfo(link Link) (Protinfo, error) {
+	return pkgHandle.LinkGetProtinfo(link)
+}
+
+// LinkGetProtinfo gets the bridge port flags of a bridge member.
+func (h *Handle) LinkGetProtinfo(link Link) (Protinfo, error) {
+	name, err := h.linkName(link)
+	if err != nil {
+		return Protinfo{}, err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return Protinfo{}, fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	port, err := bridgePortFlags(fd, name)
+	if err != nil {
+		return Protinfo{}, err
+	}
+	return parseBridgePortFlags(&port.ifBreq), nil
+}
@@ -0,0 +1,80 @@
+package netlink
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+// netlinkUnsupported reports whether err is the error of a netlink socket
+// on a kernel without netlink(4), i.e. without netlink.ko loaded.
+func netlinkUnsupported(err error) bool {
+	return errors.Is(err, unix.EAFNOSUPPORT) || errors.Is(err, unix.EPROTONOSUPPORT)
+}
+
+// routeSocket is a PF_ROUTE socket receiving the routing messages of the
+// kernel, see route(4). It is how changes are followed without netlink.
+type routeSocket struct {
+	fd int
+}
+
+// openRouteSocket opens a routing socket for the messages of the address
+// family, of all families with AF_UNSPEC. Closing done shuts it down, a
+// pending Receive then returns io.EOF.
+func openRouteSocket(family int, done <-chan struct{}, rcvbuf int, rcvTimeout *unix.Timeval) (*routeSocket, error) {
+	fd, err := unix.Socket(unix.AF_ROUTE, unix.SOCK_RAW|unix.SOCK_CLOEXEC, family)
+	if err != nil {
+		return nil, fmt.Errorf("socket error: %w", err)
+	}
+	if rcvbuf != 0 {
+		if err := unix.SetsockoptInt(fd, unix.SOL_SOCKET, unix.SO_RCVBUF, rcvbuf); err != nil {
+			unix.Close(fd)
+			return nil, fmt.Errorf("setsockopt SO_RCVBUF error: %w", err)
+		}
+	}
+	if rcvTimeout != nil {
+		if err := unix.SetsockoptTimeval(fd, unix.SOL_SOCKET, unix.SO_RCVTIMEO, rcvTimeout); err != nil {
+			unix.Close(fd)
+			return nil, fmt.Errorf("setsockopt SO_RCVTIMEO error: %w", err)
+		}
+	}
+	if done != nil {
+		// Closing the socket would not wake up a pending read and might
+		// let it read from a reused descriptor, shutting it down does.
+		go func() {
+			<-done
+			unix.Shutdown(fd, unix.SHUT_RD)
+		}()
+	}
+	return &routeSocket{fd: fd}, nil
+}
+
+// Receive returns the next routing message.
+func (s *routeSocket) Receive() ([]byte, error) {
+	buf := make([]byte, os.Getpagesize())
+	for {
+		n, err := unix.Read(s.fd, buf)
+		if err == unix.EINTR {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		if n == 0 {
+			return nil, io.EOF
+		}
+		// version and type follow the length in every routing message
+		if n < 4 || int(native.Uint16(buf[:2])) > n {
+			return nil, fmt.Errorf("invalid routing message length %d", n)
+		}
+		return buf[:n], nil
+	}
+}
+
+// Close closes the socket once nothing receives from it any more.
+func (s *routeSocket) Close() {
+	unix.Close(s.fd)
+}
@@ -0,0 +1,320 @@
+package netlink
+
+import (
+	"fmt"
+	"net"
+	"unsafe"
+
+	"github.com/oss-fun/netlink/nl"
+	"github.com/oss-fun/vnet"
+	netroute "golang.org/x/net/route"
+	"golang.org/x/sys/unix"
+)
+
+// Both ends of an epair share if_dname and if_dunit, so their drivername
+// is the same, e.g. "epair3", no matter how the ends are renamed. That
+// pairs them within a vnet; VethPeerLocation looks for an end moved into
+// another vnet.
+
+// VethPeerIndex returns the index of the peer of an epair end. The peer
+// must be in the current vnet, see VethPeerLocation for one moved away.
+func VethPeerIndex(link *Veth) (int, error) {
+	name, err := pkgHandle.linkName(link)
+	if err != nil {
+		return -1, err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return -1, fmt.Errorf("socket error: %w", err)
+	}
+	index, err := linkIndexByName(fd, name)
+	unix.Close(fd)
+	if err != nil {
+		return -1, err
+	}
+
+	drivernames, _, err := linkDriverNames()
+	if err != nil {
+		return -1, err
+	}
+	if !isEpair(drivernames[index]) {
+		return -1, fmt.Errorf("link %s is not an epair", name)
+	}
+	if peer, ok := epairPeer(drivernames, index, drivernames[index]); ok {
+		return peer, nil
+	}
+	return -1, LinkNotFoundError{fmt.Errorf("peer of %s (%s) not found in this vnet", name, drivernames[index])}
+}
+
+// isEpair reports whether the drivername is that of an epair end.
+func isEpair(drivername string) bool {
+	driver, _ := splitDriverName(drivername)
+	return driver == "epair"
+}
+
+// linkDriverNames returns the drivername and the name of every link of
+// the current vnet by index.
+func linkDriverNames() (map[int]string, map[int]string, error) {
+	rib, err := netroute.FetchRIB(unix.AF_UNSPEC, netroute.RIBTypeInterface, 0)
+	if err != nil {
+		return nil, nil, fmt.Errorf("sysctl NET_RT_IFLIST error: %w", err)
+	}
+	msgs, err := netroute.ParseRIB(netroute.RIBTypeInterface, rib)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	drivernames := make(map[int]string)
+	names := make(map[int]string)
+	for _, msg := range msgs {
+		ifm, ok := msg.(*netroute.InterfaceMessage)
+		if !ok {
+			continue
+		}
+		drivername, err := linkDriverName(ifm.Index)
+		if err != nil {
+			// the link went away in between
+			continue
+		}
+		drivernames[ifm.Index] = drivername
+		names[ifm.Index] = ifm.Name
+	}
+	return drivernames, names, nil
+}
+
+// fillVethPeers sets PeerName of the given epair ends from their peer in
+// the current vnet. A peer moved into another vnet is not looked for, see
+// VethPeerLocation. Errors are ignored, the links are left unchanged then.
+func fillVethPeers(links ...Link) {
+	var veths []*Veth
+	for _, link := range links {
+		if veth, ok := link.(*Veth); ok {
+			veths = append(veths, veth)
+		}
+	}
+	if len(veths) == 0 {
+		return
+	}
+
+	drivernames, names, err := linkDriverNames()
+	if err != nil {
+		return
+	}
+	for _, veth := range veths {
+		drivername := drivernames[veth.Index]
+		if !isEpair(drivername) {
+			continue
+		}
+		if peer, ok := epairPeer(drivernames, veth.Index, drivername); ok {
+			veth.PeerName = names[peer]
+		}
+	}
+}
+
+// VethPeerLocation returns the name of the peer of an epair end and the
+// vnet jail it is in, vnet.None() for the current vnet. The peer is
+// looked for in the current vnet first, then in the given jails in turn.
+// The kernel tells neither end where the other one went, so the jails to
+// search must be given.
+func VethPeerLocation(link *Veth, jails ...vnet.VjHandle) (string, vnet.VjHandle, error) {
+	name, err := pkgHandle.linkName(link)
+	if err != nil {
+		return "", vnet.None(), err
+	}
+	fd, err := getSocketUDP()
+	if err != nil {
+		return "", vnet.None(), fmt.Errorf("socket error: %w", err)
+	}
+	index, err := linkIndexByName(fd, name)
+	unix.Close(fd)
+	if err != nil {
+		return "", vnet.None(), err
+	}
+
+	drivernames, names, err := linkDriverNames()
+	if err != nil {
+		return "", vnet.None(), err
+	}
+	drivername := drivernames[index]
+	if !isEpair(drivername) {
+		return "", vnet.None(), fmt.Errorf("link %s is not an epair", name)
+	}
+	if peer, ok := epairPeer(drivernames, index, drivername); ok {
+		return names[peer], vnet.None(), nil
+	}
+
+	for _, jail := range jails {
+		var peerName string
+		err := nl.ExecuteAt(jail, vnet.None(), func() error {
+			drivernames, names, err := linkDriverNames()
+			if err != nil {
+				return err
+			}
+			// no end of the epair is in the jail yet
+			if peer, ok := epairPeer(drivernames, 0, drivername); ok {
+				peerName = names[peer]
+			}
+			return nil
+		})
+		if err != nil {
+			return "", vnet.None(), err
+		}
+		if peerName != "" {
+			return peerName, jail, nil
+		}
+	}
+	return "", vnet.None(), LinkNotFoundError{fmt.Errorf("peer of %s (%s) not found", name, drivername)}
+}
+
+// epairPeer returns the index of the link with the drivername other than
+// index, the other end of the epair.
+func epairPeer(drivernames map[int]string, index int, drivername string) (int, bool) {
+	for peer, dn := range drivernames {
+		if peer != index && dn == drivername {
+			return peer, true
+		}
+	}
+	return 0, false
+}
+
+// linkAddEpair creates an epair for the Veth. Both ends are renamed and
+// get their hardware address and MTU, then the peer is moved into
+// PeerNamespace and the Veth end into Namespace. Empty names keep the
+// names given by the kernel and are filled in. If any step fails, the
+// epair is destroyed again.
+func (h *Handle) linkAddEpair(veth *Veth) error {
+	peerJail, movePeer, err := namespaceJail(veth.PeerNamespace)
+	if err != nil {
+		return err
+	}
+	jail, move, err := namespaceJail(veth.Namespace)
+	if err != nil {
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %w", err)
+	}
+	defer unix.Close(fd)
+
+	var ifr Ifreq
+	copy(ifr.Name[:], "epair")
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(unix.SIOCIFCREATE2),
+		uintptr(unsafe.Pointer(&ifr)),
+	)
+	if errno != 0 {
+		return fmt.Errorf("ioctl SIOCIFCREATE2 error: %w", errno)
+	}
+
+	// the kernel names the ends of a new epair epairNa and epairNb
+	name := nl.BytesToString(ifr.Name[:])
+	b, err := atob([]byte(name))
+	if err != nil {
+		linkDestroy(fd, name)
+		return err
+	}
+	peerName := string(b)
+
+	// destroying one end destroys both, even if the peer is in a jail
+	rollback := func(err error) error {
+		linkDestroy(fd, name)
+		return err
+	}
+
+	base := veth.Attrs()
+	if base.Name == "" {
+		base.Name = name
+	} else if base.Name != name {
+		if err := h.LinkSetName(&Device{LinkAttrs{Name: name}}, base.Name); err != nil {
+			return rollback(err)
+		}
+		name = base.Name
+	}
+	if veth.PeerName == "" {
+		veth.PeerName = peerName
+	} else if veth.PeerName != peerName {
+		if err := h.LinkSetName(&Device{LinkAttrs{Name: peerName}}, veth.PeerName); err != nil {
+			return rollback(err)
+		}
+	}
+	peer := &Device{LinkAttrs{Name: veth.PeerName}}
+
+	if base.MTU > 0 {
+		if err := h.LinkSetMTU(veth, base.MTU); err != nil {
+			return rollback(err)
+		}
+		if err := h.LinkSetMTU(peer, base.MTU); err != nil {
+			return rollback(err)
+		}
+	}
+	if base.HardwareAddr != nil {
+		if err := h.vethSetHardwareAddr(veth, base.HardwareAddr); err != nil {
+			return rollback(err)
+		}
+	}
+	if veth.PeerHardwareAddr != nil {
+		if err := h.vethSetHardwareAddr(peer, veth.PeerHardwareAddr); err != nil {
+			return rollback(err)
+		}
+	}
+
+	if err := linkSetIoctlAttrs(fd, base); err != nil {
+		return rollback(err)
+	}
+	if base.Flags&net.FlagUp != 0 {
+		if err := h.LinkSetUp(veth); err != nil {
+			return rollback(err)
+		}
+	}
+	if movePeer {
+		if err := h.LinkSetNsFd(peer, peerJail); err != nil {
+			return rollback(err)
+		}
+	}
+	if base.MasterIndex != 0 {
+		if err := h.LinkSetMasterByIndex(veth, base.MasterIndex); err != nil {
+			return rollback(err)
+		}
+	}
+	if move {
+		if err := h.LinkSetNsFd(veth, jail); err != nil {
+			return rollback(err)
+		}
+	}
+	return nil
+}
+
+// namespaceJail returns the jail ID of the Namespace or PeerNamespace of
+// a link, a jail ID as int, NsFd or vnet.VjHandle, and whether the link is
+// to be moved at all.
+func namespaceJail(ns interface{}) (int, bool, error) {
+	switch ns := ns.(type) {
+	case nil:
+		return 0, false, nil
+	case int:
+		return ns, true, nil
+	case NsFd:
+		return int(ns), true, nil
+	case vnet.VjHandle:
+		if !ns.IsOpen() {
+			return 0, false, nil
+		}
+		return int(ns), true, nil
+	default:
+		return 0, false, fmt.Errorf("unsupported namespace %T, want a jail ID or vnet.VjHandle", ns)
+	}
+}
+
+// vethSetHardwareAddr sets the hardware address of an epair end, which
+// must be a unicast ethernet address.
+func (h *Handle) vethSetHardwareAddr(link Link, hwaddr net.HardwareAddr) error {
+	if len(hwaddr) != ETHER_ADDR_LEN || hwaddr[0]&1 != 0 {
+		return fmt.Errorf("invalid hardware address %s for %s", hwaddr, link.Attrs().Name)
+	}
+	return h.LinkSetHardwareAddr(link, hwaddr)
+}
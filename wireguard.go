@@ -0,0 +1,78 @@
+package netlink
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net"
+	"time"
+)
+
+// WireguardKeyLen is the length in bytes of wireguard keys.
+const WireguardKeyLen = 32
+
+// WireguardKey is a Curve25519 public, private or preshared key.
+type WireguardKey [WireguardKeyLen]byte
+
+// ParseWireguardKey parses a base64 encoded key as used by wg(8).
+func ParseWireguardKey(s string) (WireguardKey, error) {
+	var k WireguardKey
+	b, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return k, fmt.Errorf("invalid wireguard key: %v", err)
+	}
+	if len(b) != WireguardKeyLen {
+		return k, fmt.Errorf("invalid wireguard key length %d", len(b))
+	}
+	copy(k[:], b)
+	return k, nil
+}
+
+// String returns the base64 encoding of the key.
+func (k WireguardKey) String() string {
+	return base64.StdEncoding.EncodeToString(k[:])
+}
+
+// WireguardConfig is the configuration applied to a wireguard link by
+// WireguardConfigure. Nil fields are left unchanged.
+type WireguardConfig struct {
+	PrivateKey   *WireguardKey
+	ListenPort   *int
+	FirewallMark *int // the socket user cookie on FreeBSD
+	ReplacePeers bool // remove peers not listed in Peers
+	Peers        []WireguardPeerConfig
+}
+
+// WireguardPeerConfig adds, updates or removes the peer identified by
+// PublicKey. Nil fields are left unchanged.
+type WireguardPeerConfig struct {
+	PublicKey           WireguardKey
+	Remove              bool
+	PresharedKey        *WireguardKey
+	Endpoint            *net.UDPAddr
+	PersistentKeepalive *time.Duration
+	ReplaceAllowedIPs   bool // replace instead of append AllowedIPs
+	AllowedIPs          []net.IPNet
+}
+
+// WireguardDevice is the configuration and status of a wireguard link as
+// returned by WireguardGet.
+type WireguardDevice struct {
+	Name         string
+	PrivateKey   WireguardKey // only returned to privileged callers
+	PublicKey    WireguardKey
+	ListenPort   int
+	FirewallMark int
+	Peers        []WireguardPeer
+}
+
+// WireguardPeer is the configuration and status of a wireguard peer.
+type WireguardPeer struct {
+	PublicKey           WireguardKey
+	PresharedKey        WireguardKey // only returned to privileged callers
+	Endpoint            *net.UDPAddr
+	PersistentKeepalive time.Duration
+	LastHandshake       time.Time
+	RxBytes             uint64
+	TxBytes             uint64
+	AllowedIPs          []net.IPNet
+}
@@ -0,0 +1,297 @@
+package netlink
+
+import (
+	"fmt"
+	"net"
+	"time"
+	"unsafe"
+
+	"github.com/oss-fun/netlink/nv"
+	"golang.org/x/sys/unix"
+)
+
+// WireguardConfigure applies cfg to a wireguard link.
+// Equivalent to: `wg set $link ...`
+func WireguardConfigure(link Link, cfg *WireguardConfig) error {
+	return pkgHandle.WireguardConfigure(link, cfg)
+}
+
+// WireguardConfigure applies cfg to a wireguard link.
+// Equivalent to: `wg set $link ...`
+func (h *Handle) WireguardConfigure(link Link, cfg *WireguardConfig) error {
+	nvl, err := wireguardConfigToNvlist(cfg)
+	if err != nil {
+		return err
+	}
+	b, err := nvl.Pack()
+	if err != nil {
+		return err
+	}
+
+	fd, err := getSocketUDP()
+	if err != nil {
+		return fmt.Errorf("socket error: %v", err)
+	}
+	defer unix.Close(fd)
+
+	var wgd wgDataIO
+	copy(wgd.Name[:unix.IFNAMSIZ-1], link.Attrs().Name)
+	wgd.Data = unsafe.Pointer(&b[0])
+	wgd.Size = uint64(len(b))
+
+	_, _, errno := unix.Syscall(
+		unix.SYS_IOCTL,
+		uintptr(fd),
+		uintptr(SIOCSWG),
+		uintptr(unsafe.Pointer(&wgd)),
+	)
+	if errno != 0 {
+		return fmt.Errorf("ioctl SIOCSWG error: %w", errno)
+	}
+	return nil
+}
+
+// WireguardGet returns the configuration and the peer status of a
+// wireguard link. Private and preshared keys are only reported to
+// privileged callers.
+// Equivalent to: `wg show $link`
+func WireguardGet(link Link) (*WireguardDevice, error) {
+	return pkgHandle.WireguardGet(link)
+}
+
+// WireguardGet returns the configuration and the peer status of a
+// wireguard link. Private and preshared keys are only reported to
+// privileged callers.
+// Equivalent to: `wg show $link`
+func (h *Handle) WireguardGet(link Link) (*WireguardDevice, error) {
+	fd, err := getSocketUDP()
+	if err != nil {
+		return nil, fmt.Errorf("socket error: %v", err)
+	}
+	defer unix.Close(fd)
+
+	var wgd wgDataIO
+	copy(wgd.Name[:unix.IFNAMSIZ-1], link.Attrs().Name)
+
+	// The first call reports the size of the packed nvlist. Peers may be
+	// added in between, so retry as long as the kernel reports ENOSPC.
+	var buf []byte
+	for {
+		_, _, errno := unix.Syscall(
+			unix.SYS_IOCTL,
+			uintptr(fd),
+			uintptr(SIOCGWG),
+			uintptr(unsafe.Pointer(&wgd)),
+		)
+		if errno == unix.ENOSPC {
+			wgd.Size = 0
+			wgd.Data = nil
+			continue
+		}
+		if errno != 0 {
+			return nil, fmt.Errorf("ioctl SIOCGWG error: %w", errno)
+		}
+		if wgd.Data != nil {
+			break
+		}
+		if wgd.Size == 0 {
+			return nil, fmt.Errorf("ioctl SIOCGWG reported no configuration for %s", link.Attrs().Name)
+		}
+		buf = make([]byte, wgd.Size)
+		wgd.Data = unsafe.Pointer(&buf[0])
+	}
+
+	nvl, err := nv.Unpack(buf[:wgd.Size])
+	if err != nil {
+		return nil, err
+	}
+	dev, err := parseWireguardNvlist(nvl)
+	if err != nil {
+		return nil, err
+	}
+	dev.Name = link.Attrs().Name
+	return dev, nil
+}
+
+func wireguardConfigToNvlist(cfg *WireguardConfig) (*nv.List, error) {
+	nvl := nv.NewList()
+	if cfg.PrivateKey != nil {
+		nvl.AddBinary("private-key", cfg.PrivateKey[:])
+	}
+	if cfg.ListenPort != nil {
+		nvl.AddNumber("listen-port", uint64(*cfg.ListenPort))
+	}
+	if cfg.FirewallMark != nil {
+		nvl.AddNumber("user-cookie", uint64(*cfg.FirewallMark))
+	}
+	if cfg.ReplacePeers {
+		nvl.AddBool("replace-peers", true)
+	}
+	if len(cfg.Peers) == 0 {
+		return nvl, nil
+	}
+
+	peers := make([]*nv.List, 0, len(cfg.Peers))
+	for _, p := range cfg.Peers {
+		peer := nv.NewList()
+		peer.AddBinary("public-key", p.PublicKey[:])
+		if p.Remove {
+			peer.AddBool("remove", true)
+			peers = append(peers, peer)
+			continue
+		}
+		if p.PresharedKey != nil {
+			peer.AddBinary("preshared-key", p.PresharedKey[:])
+		}
+		if p.Endpoint != nil {
+			sa, err := udpAddrToSockaddr(p.Endpoint)
+			if err != nil {
+				return nil, err
+			}
+			peer.AddBinary("endpoint", sa)
+		}
+		if p.PersistentKeepalive != nil {
+			peer.AddNumber("persistent-keepalive-interval", uint64(*p.PersistentKeepalive/time.Second))
+		}
+		if p.ReplaceAllowedIPs {
+			peer.AddBool("replace-allowedips", true)
+		}
+		if len(p.AllowedIPs) > 0 {
+			aips := make([]*nv.List, 0, len(p.AllowedIPs))
+			for _, ipnet := range p.AllowedIPs {
+				aip := nv.NewList()
+				ones, bits := ipnet.Mask.Size()
+				if ip4 := ipnet.IP.To4(); ip4 != nil && bits == 32 {
+					aip.AddBinary("ipv4", ip4)
+				} else if ip6 := ipnet.IP.To16(); ip6 != nil && bits == 128 {
+					aip.AddBinary("ipv6", ip6)
+				} else {
+					return nil, fmt.Errorf("invalid allowed ip %s", ipnet.String())
+				}
+				aip.AddNumber("cidr", uint64(ones))
+				aips = append(aips, aip)
+			}
+			peer.AddListArray("allowed-ips", aips)
+		}
+		peers = append(peers, peer)
+	}
+	nvl.AddListArray("peers", peers)
+	return nvl, nil
+}
+
+func parseWireguardNvlist(nvl *nv.List) (*WireguardDevice, error) {
+	dev := &WireguardDevice{}
+	if port, ok := nvl.GetNumber("listen-port"); ok {
+		dev.ListenPort = int(port)
+	}
+	if cookie, ok := nvl.GetNumber("user-cookie"); ok {
+		dev.FirewallMark = int(cookie)
+	}
+	if b, ok := nvl.GetBinary("public-key"); ok {
+		copy(dev.PublicKey[:], b)
+	}
+	if b, ok := nvl.GetBinary("private-key"); ok {
+		copy(dev.PrivateKey[:], b)
+	}
+
+	peers, _ := nvl.GetListArray("peers")
+	for _, p := range peers {
+		var peer WireguardPeer
+		if b, ok := p.GetBinary("public-key"); ok {
+			copy(peer.PublicKey[:], b)
+		}
+		if b, ok := p.GetBinary("preshared-key"); ok {
+			copy(peer.PresharedKey[:], b)
+		}
+		if b, ok := p.GetBinary("endpoint"); ok {
+			ep, err := sockaddrToUDPAddr(b)
+			if err != nil {
+				return nil, err
+			}
+			peer.Endpoint = ep
+		}
+		if b, ok := p.GetBinary("last-handshake-time"); ok && len(b) >= 16 {
+			// struct wg_timespec64
+			sec := int64(native.Uint64(b[0:8]))
+			nsec := int64(native.Uint64(b[8:16]))
+			if sec != 0 || nsec != 0 {
+				peer.LastHandshake = time.Unix(sec, nsec)
+			}
+		}
+		if ka, ok := p.GetNumber("persistent-keepalive-interval"); ok {
+			peer.PersistentKeepalive = time.Duration(ka) * time.Second
+		}
+		peer.RxBytes, _ = p.GetNumber("rx-bytes")
+		peer.TxBytes, _ = p.GetNumber("tx-bytes")
+
+		aips, _ := p.GetListArray("allowed-ips")
+		for _, aip := range aips {
+			cidr, _ := aip.GetNumber("cidr")
+			if ip, ok := aip.GetBinary("ipv4"); ok && len(ip) == net.IPv4len {
+				peer.AllowedIPs = append(peer.AllowedIPs, net.IPNet{
+					IP:   net.IP(ip).To4(),
+					Mask: net.CIDRMask(int(cidr), 32),
+				})
+			} else if ip, ok := aip.GetBinary("ipv6"); ok && len(ip) == net.IPv6len {
+				peer.AllowedIPs = append(peer.AllowedIPs, net.IPNet{
+					IP:   net.IP(ip),
+					Mask: net.CIDRMask(int(cidr), 128),
+				})
+			}
+		}
+		dev.Peers = append(dev.Peers, peer)
+	}
+	return dev, nil
+}
+
+// udpAddrToSockaddr encodes addr as a struct sockaddr_in or sockaddr_in6.
+func udpAddrToSockaddr(addr *net.UDPAddr) ([]byte, error) {
+	if ip4 := addr.IP.To4(); ip4 != nil {
+		b := make([]byte, unix.SizeofSockaddrInet4)
+		b[0] = unix.SizeofSockaddrInet4
+		b[1] = unix.AF_INET
+		networkOrder.PutUint16(b[2:4], uint16(addr.Port))
+		copy(b[4:8], ip4)
+		return b, nil
+	}
+	ip6 := addr.IP.To16()
+	if ip6 == nil {
+		return nil, fmt.Errorf("invalid endpoint address %s", addr.String())
+	}
+	b := make([]byte, unix.SizeofSockaddrInet6)
+	b[0] = unix.SizeofSockaddrInet6
+	b[1] = unix.AF_INET6
+	networkOrder.PutUint16(b[2:4], uint16(addr.Port))
+	copy(b[8:24], ip6)
+	if addr.Zone != "" {
+		ifi, err := net.InterfaceByName(addr.Zone)
+		if err != nil {
+			return nil, fmt.Errorf("invalid endpoint zone %q: %v", addr.Zone, err)
+		}
+		native.PutUint32(b[24:28], uint32(ifi.Index))
+	}
+	return b, nil
+}
+
+// sockaddrToUDPAddr decodes a struct sockaddr_in or sockaddr_in6.
+func sockaddrToUDPAddr(b []byte) (*net.UDPAddr, error) {
+	if len(b) >= unix.SizeofSockaddrInet4 && b[1] == unix.AF_INET {
+		return &net.UDPAddr{
+			IP:   net.IPv4(b[4], b[5], b[6], b[7]),
+			Port: int(networkOrder.Uint16(b[2:4])),
+		}, nil
+	}
+	if len(b) >= unix.SizeofSockaddrInet6 && b[1] == unix.AF_INET6 {
+		addr := &net.UDPAddr{
+			IP:   append(net.IP(nil), b[8:24]...),
+			Port: int(networkOrder.Uint16(b[2:4])),
+		}
+		if scope := native.Uint32(b[24:28]); scope != 0 {
+			if ifi, err := net.InterfaceByIndex(int(scope)); err == nil {
+				addr.Zone = ifi.Name
+			}
+		}
+		return addr, nil
+	}
+	return nil, fmt.Errorf("invalid sockaddr %x", b)
+}
@@ -0,0 +1,127 @@
+//go:build freebsd
+// +build freebsd
+
+package netlink
+
+import (
+	"crypto/rand"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestWireguardKeyParse(t *testing.T) {
+	var key WireguardKey
+	if _, err := rand.Read(key[:]); err != nil {
+		t.Fatal(err)
+	}
+	parsed, err := ParseWireguardKey(key.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if parsed != key {
+		t.Fatalf("key is %s, should be %s", parsed, key)
+	}
+	if _, err := ParseWireguardKey("Zm9v"); err == nil {
+		t.Fatal("short key parsed without error")
+	}
+}
+
+func TestWireguardSockaddr(t *testing.T) {
+	for _, addr := range []*net.UDPAddr{
+		{IP: net.ParseIP("192.0.2.1").To4(), Port: 51820},
+		{IP: net.ParseIP("2001:db8::1"), Port: 443},
+	} {
+		sa, err := udpAddrToSockaddr(addr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		res, err := sockaddrToUDPAddr(sa)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !res.IP.Equal(addr.IP) || res.Port != addr.Port {
+			t.Fatalf("endpoint is %s, should be %s", res, addr)
+		}
+	}
+}
+
+func TestWireguardConfigure(t *testing.T) {
+	tearDown := setUpNetlinkTest(t)
+	defer tearDown()
+
+	wg := &Wireguard{LinkAttrs: LinkAttrs{Name: "wg0"}}
+	if err := LinkAdd(wg); err != nil {
+		t.Fatal(err)
+	}
+	defer LinkDel(wg)
+
+	var priv, peerKey, psk WireguardKey
+	for _, k := range []*WireguardKey{&priv, &peerKey, &psk} {
+		if _, err := rand.Read(k[:]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	port := 51820
+	keepalive := 25 * time.Second
+	_, allowed, _ := net.ParseCIDR("10.10.0.0/16")
+	endpoint := &net.UDPAddr{IP: net.ParseIP("192.0.2.1").To4(), Port: 51821}
+
+	err := WireguardConfigure(wg, &WireguardConfig{
+		PrivateKey: &priv,
+		ListenPort: &port,
+		Peers: []WireguardPeerConfig{{
+			PublicKey:           peerKey,
+			PresharedKey:        &psk,
+			Endpoint:            endpoint,
+			PersistentKeepalive: &keepalive,
+			AllowedIPs:          []net.IPNet{*allowed},
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dev, err := WireguardGet(wg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev.ListenPort != port {
+		t.Fatalf("listen port is %d, should be %d", dev.ListenPort, port)
+	}
+	if dev.PrivateKey != priv {
+		t.Fatal("private key mismatch")
+	}
+	if len(dev.Peers) != 1 {
+		t.Fatalf("got %d peers, should be 1", len(dev.Peers))
+	}
+	peer := dev.Peers[0]
+	if peer.PublicKey != peerKey || peer.PresharedKey != psk {
+		t.Fatal("peer key mismatch")
+	}
+	if peer.Endpoint == nil || !peer.Endpoint.IP.Equal(endpoint.IP) || peer.Endpoint.Port != endpoint.Port {
+		t.Fatalf("peer endpoint is %v, should be %v", peer.Endpoint, endpoint)
+	}
+	if peer.PersistentKeepalive != keepalive {
+		t.Fatalf("keepalive is %v, should be %v", peer.PersistentKeepalive, keepalive)
+	}
+	if len(peer.AllowedIPs) != 1 || peer.AllowedIPs[0].String() != allowed.String() {
+		t.Fatalf("allowed ips are %v, should be [%v]", peer.AllowedIPs, allowed)
+	}
+	if !peer.LastHandshake.IsZero() {
+		t.Fatalf("unexpected handshake at %v", peer.LastHandshake)
+	}
+
+	err = WireguardConfigure(wg, &WireguardConfig{
+		Peers: []WireguardPeerConfig{{PublicKey: peerKey, Remove: true}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if dev, err = WireguardGet(wg); err != nil {
+		t.Fatal(err)
+	}
+	if len(dev.Peers) != 0 {
+		t.Fatalf("got %d peers after remove, should be 0", len(dev.Peers))
+	}
+}